@@ -7,61 +7,9 @@ import (
 
 	"github.com/IBM/sarama"
 	"github.com/brianvoe/gofakeit/v7"
-)
-
-type Order struct {
-	OrderUID        string    `json:"order_uid"`
-	TrackNumber     string    `json:"track_number"`
-	Entry           string    `json:"entry"`
-	Delivery        Delivery  `json:"delivery"`
-	Payment         Payment   `json:"payment"`
-	Items           []Item    `json:"items"`
-	Locale          string    `json:"locale"`
-	InternalSig     string    `json:"internal_signature"`
-	CustomerID      string    `json:"customer_id"`
-	DeliveryService string    `json:"delivery_service"`
-	ShardKey        int16     `json:"shardkey,string"`
-	SmID            int       `json:"sm_id"`
-	DateCreated     time.Time `json:"date_created"`
-	OofShard        int16     `json:"oof_shard,string"`
-}
-
-type Delivery struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
-	Zip     string `json:"zip"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Region  string `json:"region"`
-	Email   string `json:"email"`
-}
 
-type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
-}
-
-type Item struct {
-	ChrtID      int64  `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	Rid         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int64  `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
-}
+	"order-service/internal/model"
+)
 
 func main() {
 	// конфиг Kafka producer
@@ -86,6 +34,7 @@ func main() {
 
 		msg := &sarama.ProducerMessage{
 			Topic: "orders",
+			Key:   sarama.StringEncoder(order.OrderUID),
 			Value: sarama.ByteEncoder(data),
 		}
 
@@ -101,19 +50,20 @@ func main() {
 	}
 }
 
-func generateFakeOrder() Order {
+func generateFakeOrder() model.Order {
 	uid := gofakeit.UUID()
 	track := gofakeit.LetterN(4) + gofakeit.DigitN(8)
 
-	amount := gofakeit.Number(500, 5000)
+	// суммы согласованы так же, как их проверяет сервис: amount = goods_total + delivery_cost
+	goodsTotal := gofakeit.Number(300, 4000)
 	deliveryCost := gofakeit.Number(200, 1000)
-	goodsTotal := amount - deliveryCost
+	amount := goodsTotal + deliveryCost
 
-	return Order{
+	return model.Order{
 		OrderUID:    uid,
 		TrackNumber: track,
 		Entry:       gofakeit.RandomString([]string{"WBIL", "MKT", "SHOP"}),
-		Delivery: Delivery{
+		Delivery: model.Delivery{
 			Name:    gofakeit.Name(),
 			Phone:   gofakeit.Phone(),
 			Zip:     gofakeit.Zip(),
@@ -122,7 +72,7 @@ func generateFakeOrder() Order {
 			Region:  gofakeit.State(),
 			Email:   gofakeit.Email(),
 		},
-		Payment: Payment{
+		Payment: model.Payment{
 			Transaction:  uid,
 			RequestID:    "",
 			Currency:     "USD",
@@ -134,7 +84,7 @@ func generateFakeOrder() Order {
 			GoodsTotal:   goodsTotal,
 			CustomFee:    0,
 		},
-		Items: []Item{
+		Items: []model.Item{
 			{
 				ChrtID:      gofakeit.Int64(),
 				TrackNumber: track,
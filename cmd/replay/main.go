@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"order-service/internal/dlq"
+)
+
+// replay перекладывает сообщения из DLQ обратно в топик заказов.
+// Прогресс хранится в отдельной consumer group, поэтому повторный запуск
+// продолжает с места остановки. Команда завершается, если за -idle не пришло
+// ни одного сообщения.
+func main() {
+	brokers := flag.String("brokers", "localhost:9092", "comma-separated Kafka brokers")
+	from := flag.String("from", "orders.dlq", "dead-letter topic to read")
+	to := flag.String("to", "", "target topic (defaults to the original topic from message headers)")
+	group := flag.String("group", "order-service-dlq-replay", "consumer group used to track replay progress")
+	stage := flag.String("stage", "", "replay only messages failed at this stage (parse, validate, persist)")
+	idle := flag.Duration("idle", 10*time.Second, "stop after this long without new messages")
+	flag.Parse()
+
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+
+	addrs := strings.Split(*brokers, ",")
+	producer, err := sarama.NewSyncProducer(addrs, config)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka producer: %v", err)
+	}
+	defer producer.Close()
+
+	cg, err := sarama.NewConsumerGroup(addrs, *group, config)
+	if err != nil {
+		log.Fatalf("Failed to create consumer group: %v", err)
+	}
+	defer cg.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	h := &replayer{producer: producer, to: *to, stage: *stage}
+	h.touch()
+	go func() {
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if time.Since(time.Unix(0, h.last.Load())) > *idle {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	log.Printf("Replaying %s, idle timeout %s", *from, *idle)
+	for ctx.Err() == nil {
+		if err := cg.Consume(ctx, []string{*from}, h); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				break
+			}
+			log.Println("Consumer group error:", err)
+			time.Sleep(time.Second)
+		}
+	}
+	log.Printf("Replay finished: %d replayed, %d skipped", h.replayed.Load(), h.skipped.Load())
+}
+
+type replayer struct {
+	producer sarama.SyncProducer
+	to       string
+	stage    string
+
+	last     atomic.Int64
+	replayed atomic.Int64
+	skipped  atomic.Int64
+}
+
+func (r *replayer) touch() { r.last.Store(time.Now().UnixNano()) }
+
+func (r *replayer) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+func (r *replayer) Cleanup(sess sarama.ConsumerGroupSession) error {
+	sess.Commit()
+	return nil
+}
+
+func (r *replayer) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-sess.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			r.touch()
+			if r.stage != "" && dlq.Header(msg.Headers, dlq.HeaderStage) != r.stage {
+				r.skipped.Add(1)
+				sess.MarkMessage(msg, "")
+				continue
+			}
+
+			topic := r.to
+			if topic == "" {
+				topic = dlq.Header(msg.Headers, dlq.HeaderTopic)
+			}
+			if topic == "" {
+				topic = "orders"
+			}
+			// номер попытки переносим, чтобы сервис увеличил его при повторном отказе;
+			// время исходного сообщения - чтобы старый снимок не затёр более новый
+			out := &sarama.ProducerMessage{
+				Topic:     topic,
+				Value:     sarama.ByteEncoder(msg.Value),
+				Timestamp: msg.Timestamp,
+				Headers: []sarama.RecordHeader{{
+					Key:   []byte(dlq.HeaderAttempt),
+					Value: []byte(dlq.Header(msg.Headers, dlq.HeaderAttempt)),
+				}},
+			}
+			if msg.Key != nil {
+				out.Key = sarama.ByteEncoder(msg.Key)
+			}
+			if _, _, err := r.producer.SendMessage(out); err != nil {
+				return err
+			}
+			sess.MarkMessage(msg, "")
+			r.replayed.Add(1)
+			log.Printf("Replayed %s/%d@%d to %s", msg.Topic, msg.Partition, msg.Offset, topic)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-service/internal/apperr"
+	"order-service/internal/cache"
+)
+
+const (
+	defaultAdminKeysLimit = 100
+	maxAdminKeysLimit     = 1000
+)
+
+// adminAuth пропускает только запросы с Authorization: Bearer <token>.
+func adminAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeError(w, r, apperr.New(apperr.Unauthenticated, "admin token required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// registerAdmin регистрирует /admin/cache/*.
+func (a *App) registerAdmin(mux *http.ServeMux, token string) {
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, adminAuth(token, h))
+	}
+	handle("GET /admin/cache/stats", a.adminCacheStatsHandler)
+	handle("GET /admin/cache/keys", a.adminCacheKeysHandler)
+	handle("DELETE /admin/cache/{id}", a.adminCacheDeleteHandler)
+	handle("POST /admin/cache/flush", a.adminCacheFlushHandler)
+	handle("POST /admin/cache/warmup", a.adminCacheWarmupHandler)
+}
+
+type cacheStatsResponse struct {
+	Capacity    int    `json:"capacity"`
+	Len         int    `json:"len"`
+	Bytes       int64  `json:"bytes"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Rejections  uint64 `json:"rejections"`
+}
+
+func newCacheStats(c cache.Cache) *cacheStatsResponse {
+	st := c.Stats()
+	return &cacheStatsResponse{
+		Capacity:    c.Capacity(),
+		Len:         st.Len,
+		Bytes:       st.Bytes,
+		Hits:        st.Hits,
+		Misses:      st.Misses,
+		Evictions:   st.Evictions,
+		Expirations: st.Expirations,
+		Rejections:  st.Rejections,
+	}
+}
+
+func (a *App) adminCacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	resp := struct {
+		Orders  *cacheStatsResponse `json:"orders"`
+		Missing *cacheStatsResponse `json:"missing,omitempty"`
+		Warmup  checkResult         `json:"warmup"`
+	}{
+		Orders: newCacheStats(a.Cache),
+		Warmup: a.checkWarmup(),
+	}
+	if a.Missing != nil {
+		resp.Missing = newCacheStats(a.Missing)
+	}
+	if a.warmup.running.Load() {
+		resp.Warmup.State = "in progress"
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type cacheKey struct {
+	Key       string   `json:"key"`
+	SizeBytes int64    `json:"size_bytes"`
+	AgeSec    float64  `json:"age_seconds"`
+	ExpiresIn *float64 `json:"expires_in_seconds,omitempty"`
+}
+
+// adminCacheKeysHandler отдаёт ключи по возрастанию; next_cursor - последний
+// ключ страницы, его нужно передать в ?cursor= за следующей.
+func (a *App) adminCacheKeysHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAdminKeysLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxAdminKeysLimit {
+			writeError(w, r, apperr.New(apperr.Invalid, "limit must be between 1 and %d", maxAdminKeysLimit))
+			return
+		}
+		limit = n
+	}
+
+	now := time.Now()
+	infos := a.Cache.Keys(r.URL.Query().Get("cursor"), limit)
+	resp := struct {
+		Keys       []cacheKey `json:"keys"`
+		NextCursor string     `json:"next_cursor,omitempty"`
+	}{Keys: make([]cacheKey, 0, len(infos))}
+	for _, ki := range infos {
+		k := cacheKey{
+			Key:       ki.Key,
+			SizeBytes: ki.Size,
+			AgeSec:    now.Sub(ki.StoredAt).Round(time.Millisecond).Seconds(),
+		}
+		if !ki.ExpiresAt.IsZero() {
+			in := ki.ExpiresAt.Sub(now).Round(time.Millisecond).Seconds()
+			k.ExpiresIn = &in
+		}
+		resp.Keys = append(resp.Keys, k)
+	}
+	if len(infos) == limit {
+		resp.NextCursor = infos[len(infos)-1].Key
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *App) adminCacheDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := checkUUID("order id", r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if a.Missing != nil {
+		a.Missing.Delete(id)
+	}
+	a.forgetLoad(id)
+	if !a.Cache.Delete(id) {
+		writeError(w, r, apperr.New(apperr.NotFound, "order %q is not cached", id))
+		return
+	}
+	loggerFrom(r.Context()).Info("Cache entry deleted by admin", "order_uid", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *App) adminCacheFlushHandler(w http.ResponseWriter, r *http.Request) {
+	n := a.Cache.Flush()
+	if a.Missing != nil {
+		a.Missing.Flush()
+	}
+	loggerFrom(r.Context()).Info("Cache flushed by admin", "orders", n)
+	writeJSON(w, http.StatusOK, map[string]int{"flushed": n})
+}
+
+// adminCacheWarmupHandler запускает прогрев в фоне; ?strategy= переопределяет
+// стратегию из конфигурации. Ход прогрева виден в /admin/cache/stats.
+func (a *App) adminCacheWarmupHandler(w http.ResponseWriter, r *http.Request) {
+	strategy := r.URL.Query().Get("strategy")
+	switch strategy {
+	case "":
+		strategy = a.WarmupStrategy
+	case warmupRecent, warmupPopular:
+	default:
+		writeError(w, r, apperr.New(apperr.Invalid, "strategy must be %s or %s", warmupRecent, warmupPopular))
+		return
+	}
+	if strategy == warmupPopular && a.AccessLog == nil {
+		writeError(w, r, apperr.New(apperr.Invalid, "popular warmup needs cache.access_log"))
+		return
+	}
+	if a.warmup.running.Load() {
+		writeError(w, r, apperr.New(apperr.Conflict, errWarmupRunning.Error()))
+		return
+	}
+
+	// прогрев переживает запрос, но не остановку сервиса
+	ctx := withLogger(a.ctx, loggerFrom(r.Context()))
+	go func() {
+		err := a.warmupCache(ctx, strategy)
+		if err != nil && !errors.Is(err, errWarmupRunning) {
+			slog.Error("Cache warmup failed", "error", err)
+		}
+	}()
+	loggerFrom(r.Context()).Info("Cache warmup started by admin", "strategy", strategy)
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "started", "strategy": strategy})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -2,280 +2,286 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/google/uuid"
+
+	"order-service/internal/apperr"
+	"order-service/internal/dlq"
+	"order-service/internal/model"
+	"order-service/internal/repository"
+	"order-service/internal/validation"
 )
 
-const kafkaTopic = "orders"
+const consumerRetryDelay = 5 * time.Second
 
 func (a *App) startKafkaConsumer(ctx context.Context) {
 	config := sarama.NewConfig()
 	config.Consumer.Return.Errors = true
+	// при первом запуске группы читаем топик с начала, дальше - с закоммиченного оффсета
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{
+		sarama.NewBalanceStrategyRoundRobin(),
+	}
 
-	var consumer sarama.Consumer
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+
+	var group sarama.ConsumerGroup
+	var producer sarama.SyncProducer
 	var err error
 
+	a.consumer.setState(consumerConnecting)
+	defer a.consumer.setState(consumerStopped)
+
 	for i := 0; i < 10; i++ {
-		consumer, err = sarama.NewConsumer(a.KafkaBrokers, config)
+		group, err = sarama.NewConsumerGroup(a.KafkaBrokers, a.KafkaGroupID, config)
+		if err == nil {
+			producer, err = sarama.NewSyncProducer(a.KafkaBrokers, config)
+			if err != nil {
+				group.Close()
+			}
+		}
 		if err != nil {
-			log.Printf("Attempt %d: Kafka not available, retrying...", i+1)
+			a.consumer.setError(err)
+			slog.Warn("Kafka not available, retrying", "attempt", i+1, "error", err)
 			time.Sleep(5 * time.Second)
 			continue
 		}
 		break
 	}
 	if err != nil {
-		log.Fatalf("Failed to connect to Kafka: %v", err)
+		fatal("Failed to connect to Kafka", err)
 	}
-	defer consumer.Close()
+	defer group.Close()
+	defer producer.Close()
 
-	pc, err := consumer.ConsumePartition(kafkaTopic, 0, sarama.OffsetNewest)
+	go func() {
+		for err := range group.Errors() {
+			a.consumer.setError(err)
+			slog.Error("Kafka error", "error", err)
+		}
+	}()
+
+	a.consumer.setState(consumerConnected)
+	topics := []string{a.KafkaTopic}
+	if a.StatusTopic != "" {
+		topics = append(topics, a.StatusTopic)
+	}
+	slog.Info("Connected to Kafka, joining consumer group", "group", a.KafkaGroupID, "topics", topics)
+
+	handler := &orderConsumer{app: a, dlq: producer}
+	for {
+		// Consume блокируется на время одной сессии группы и возвращается при ребалансе
+		if err := group.Consume(ctx, topics, handler); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return
+			}
+			a.consumer.setError(err)
+			slog.Error("Consumer group error", "error", err)
+			time.Sleep(time.Second)
+		}
+		if ctx.Err() != nil {
+			slog.Info("Kafka consumer shutting down")
+			return
+		}
+	}
+}
+
+// orderConsumer реализует sarama.ConsumerGroupHandler для топика заказов.
+type orderConsumer struct {
+	app *App
+	dlq sarama.SyncProducer
+}
+
+// deadLetter перекладывает необработанное сообщение в DLQ-топик.
+func (h *orderConsumer) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, cause error) error {
+	stage := dlq.StagePersist
+	var ie *ingestError
+	if errors.As(cause, &ie) {
+		stage = ie.Stage
+	}
+	kafkaMessagesFailed.WithLabelValues(msg.Topic, partitionLabel(msg.Partition), stage).Inc()
+	_, _, err := h.dlq.SendMessage(dlq.Message(h.app.DLQTopic, msg, stage, cause))
 	if err != nil {
-		log.Fatal("Error consuming partition:", err)
+		return err
 	}
-	defer pc.Close()
+	loggerFrom(ctx).Warn("Message moved to DLQ", "dlq_topic", h.app.DLQTopic, "stage", stage)
+	return nil
+}
+
+func (h *orderConsumer) Setup(sess sarama.ConsumerGroupSession) error {
+	slog.Info("Consumer group session started", "generation", sess.GenerationID(), "claims", sess.Claims())
+	h.app.consumer.setClaims(sess.Claims())
+	return nil
+}
 
-	log.Println("Connected to Kafka! Listening for messages...")
+func (h *orderConsumer) Cleanup(sess sarama.ConsumerGroupSession) error {
+	// сбрасываем отмеченные оффсеты до того, как партиции уйдут другому участнику
+	sess.Commit()
+	h.app.consumer.setClaims(nil)
+	slog.Info("Consumer group session ended", "generation", sess.GenerationID())
+	return nil
+}
 
+func (h *orderConsumer) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for {
 		select {
-		case <-ctx.Done():
-			log.Println("Kafka consumer shutting down...")
-			return
-		case msg := <-pc.Messages():
-			if msg != nil {
-				a.processKafkaMessage(msg.Value)
+		case <-sess.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			partition := partitionLabel(msg.Partition)
+			kafkaMessagesConsumed.WithLabelValues(msg.Topic, partition).Inc()
+			kafkaConsumerLag.WithLabelValues(msg.Topic, partition).Set(float64(claim.HighWaterMarkOffset() - msg.Offset - 1))
+
+			logger := slog.Default().With("topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+			ctx := withLogger(sess.Context(), logger)
+
+			process := h.app.processKafkaMessage
+			if msg.Topic == h.app.StatusTopic {
+				process = h.app.processStatusMessage
+			}
+			err := process(ctx, msg)
+			if ctx.Err() != nil {
+				// сессия закрывается посреди обработки: оффсет не отмечаем
+				return nil
 			}
-		case err := <-pc.Errors():
 			if err != nil {
-				log.Println("Kafka error:", err)
+				logger.Error("Failed to process message", "error", err)
+				if err := h.deadLetter(ctx, msg, err); err != nil {
+					// оффсет не отмечаем: после перезапуска сессии сообщение будет прочитано повторно
+					logger.Error("Failed to publish message to DLQ", "error", err)
+					select {
+					case <-time.After(consumerRetryDelay):
+					case <-sess.Context().Done():
+					}
+					return err
+				}
 			}
+			sess.MarkMessage(msg, "")
 		}
 	}
 }
 
-type Order struct {
-	OrderUID        string    `json:"order_uid"`
-	TrackNumber     string    `json:"track_number"`
-	Entry           string    `json:"entry"`
-	Delivery        Delivery  `json:"delivery"`
-	Payment         Payment   `json:"payment"`
-	Items           []Item    `json:"items"`
-	Locale          string    `json:"locale"`
-	InternalSig     string    `json:"internal_signature"`
-	CustomerID      string    `json:"customer_id"`
-	DeliveryService string    `json:"delivery_service"`
-	ShardKey        int16     `json:"shardkey,string"`
-	SmID            int       `json:"sm_id"`
-	DateCreated     time.Time `json:"date_created"`
-	OofShard        int16     `json:"oof_shard,string"`
+// ingestError описывает, на какой стадии обработки упало сообщение.
+type ingestError struct {
+	Stage string
+	Err   error
 }
 
-type Delivery struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
-	Zip     string `json:"zip"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Region  string `json:"region"`
-	Email   string `json:"email"`
-}
+func (e *ingestError) Error() string { return e.Stage + ": " + e.Err.Error() }
+
+func (e *ingestError) Unwrap() error { return e.Err }
 
-type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+func sourceOf(msg *sarama.ConsumerMessage) *model.Source {
+	return &model.Source{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset}
 }
 
-type Item struct {
-	ChrtID      int64  `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	Rid         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int64  `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+func parseError(format string, args ...any) error {
+	return &ingestError{Stage: dlq.StageParse, Err: fmt.Errorf(format, args...)}
 }
 
-func (a *App) processKafkaMessage(msg []byte) {
-	log.Printf("Received message: %s", msg)
+func (a *App) processKafkaMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	// само сообщение не логируем: в нём персональные данные покупателя
+	loggerFrom(ctx).Debug("Received message", "bytes", len(msg.Value))
 
-	var order Order
-	if err := json.Unmarshal(msg, &order); err != nil {
-		log.Println("Invalid order JSON:", err)
-		return
+	order, err := model.Parse(msg.Value)
+	if err != nil {
+		return parseError("invalid order JSON: %w", err)
 	}
+	logger := loggerFrom(ctx).With("order_uid", order.OrderUID)
+	ctx = withLogger(ctx, logger)
 
-	orderID, err := uuid.Parse(order.OrderUID)
-	if err != nil {
-		log.Println("Invalid order_uid:", err)
-		return
+	if errs := order.Validate(); len(errs) > 0 {
+		return &ingestError{Stage: dlq.StageValidate, Err: errs}
 	}
-	txUUID, err := uuid.Parse(order.Payment.Transaction)
-	if err != nil {
-		log.Println("Invalid transaction:", err)
-		return
+	if order.UpdatedAt.IsZero() {
+		// без updated_at порядок версий определяем по времени публикации
+		order.UpdatedAt = msg.Timestamp
 	}
+	order = order.Canonical()
 
-	tx, err := a.DB.Begin()
-	if err != nil {
-		log.Println("failed to begin tx:", err)
-		return
+	err = a.Retry.do(ctx, func() error {
+		start := time.Now()
+		err := a.Orders.Upsert(ctx, &order, sourceOf(msg))
+		result := "ok"
+		switch {
+		case errors.Is(err, repository.ErrStale):
+			result = "stale"
+		case err != nil:
+			result = "error"
+		}
+		ingestTxDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+		return err
+	})
+	if errors.Is(err, repository.ErrStale) {
+		// уже записан более свежий снимок: сообщение обработано, но ничего не меняет
+		ingestSkippedStale.Inc()
+		logger.Info("Stale order version skipped", "updated_at", order.UpdatedAt)
+		return nil
 	}
-	defer tx.Rollback()
-	
-	_, err = tx.Exec(`
-		INSERT INTO orders (
-			order_uid, track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
-		)
-		ON CONFLICT (order_uid) DO UPDATE
-		SET track_number = EXCLUDED.track_number,
-			entry = EXCLUDED.entry,
-			locale = EXCLUDED.locale,
-			internal_signature = EXCLUDED.internal_signature,
-			customer_id = EXCLUDED.customer_id,
-			delivery_service = EXCLUDED.delivery_service,
-			shardkey = EXCLUDED.shardkey,
-			sm_id = EXCLUDED.sm_id,
-			date_created = EXCLUDED.date_created,
-			oof_shard = EXCLUDED.oof_shard`,
-		sql.Named("order_uid", orderID),
-		sql.Named("track_number", order.TrackNumber),
-		sql.Named("entry", order.Entry),
-		sql.Named("locale", order.Locale),
-		sql.Named("internal_signature", order.InternalSig),
-		sql.Named("customer_id", order.CustomerID),
-		sql.Named("delivery_service", order.DeliveryService),
-		sql.Named("shardkey", order.ShardKey),
-		sql.Named("sm_id", order.SmID),
-		sql.Named("date_created", order.DateCreated),
-		sql.Named("oof_shard", order.OofShard),
-	)
 	if err != nil {
-		log.Println("failed to insert order:", err)
-		return
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &ingestError{Stage: dlq.StagePersist, Err: err}
 	}
 
-	_, err = tx.Exec(`DELETE FROM deliveries WHERE order_uid=$1`,
-		sql.Named("order_uid", orderID))
-	if err != nil {
-		log.Println("failed to delete old delivery:", err)
-		return
+	// в кэш кладём каноническое представление, а не исходное сообщение
+	a.forgetLoad(order.OrderUID)
+	if data, err := model.Encode(order); err != nil {
+		logger.Error("Failed to serialize order for cache", "error", err)
+	} else {
+		a.cacheOrder(order.OrderUID, data)
 	}
-	_, err = tx.Exec(`
-		INSERT INTO deliveries (id, order_uid, name, phone, zip, city, address, region, email)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-		sql.Named("id", uuid.New()),
-		sql.Named("order_uid", orderID),
-		sql.Named("name", order.Delivery.Name),
-		sql.Named("phone", order.Delivery.Phone),
-		sql.Named("zip", order.Delivery.Zip),
-		sql.Named("city", order.Delivery.City),
-		sql.Named("address", order.Delivery.Address),
-		sql.Named("region", order.Delivery.Region),
-		sql.Named("email", order.Delivery.Email),
-	)
-	if err != nil {
-		log.Println("failed to insert delivery:", err)
-		return
+	if a.Missing != nil {
+		a.Missing.Delete(order.OrderUID)
 	}
+	a.publishInvalidation(ctx, order.OrderUID, order.Version)
+	logger.Info("Order saved to DB and cache", "order", order)
+	return nil
+}
 
-	_, err = tx.Exec(`DELETE FROM payments WHERE order_uid=$1`,
-		sql.Named("order_uid", orderID))
-	if err != nil {
-		log.Println("failed to delete old payment:", err)
-		return
-	}
-	reqID := sql.NullString{String: order.Payment.RequestID, Valid: order.Payment.RequestID != ""}
-	_, err = tx.Exec(`
-		INSERT INTO payments (
-			id, order_uid, transaction, request_id, currency, provider,
-			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
-		)`,
-		sql.Named("id", uuid.New()),
-		sql.Named("order_uid", orderID),
-		sql.Named("transaction", txUUID),
-		sql.Named("request_id", reqID),
-		sql.Named("currency", order.Payment.Currency),
-		sql.Named("provider", order.Payment.Provider),
-		sql.Named("amount", order.Payment.Amount),
-		sql.Named("payment_dt", order.Payment.PaymentDT),
-		sql.Named("bank", order.Payment.Bank),
-		sql.Named("delivery_cost", order.Payment.DeliveryCost),
-		sql.Named("goods_total", order.Payment.GoodsTotal),
-		sql.Named("custom_fee", order.Payment.CustomFee),
-	)
-	if err != nil {
-		log.Println("failed to insert payment:", err)
-		return
+// processStatusMessage применяет событие смены статуса. Недопустимый переход
+// уходит в DLQ как ошибка валидации, событие по ещё не записанному заказу -
+// как ошибка записи, чтобы его можно было переиграть позже.
+func (a *App) processStatusMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var ev model.StatusChange
+	if err := json.Unmarshal(msg.Value, &ev); err != nil {
+		return parseError("invalid status event JSON: %w", err)
 	}
+	ctx = withLogger(ctx, loggerFrom(ctx).With("order_uid", ev.OrderUID))
 
-	_, err = tx.Exec(`DELETE FROM items WHERE order_uid=$1`,
-		sql.Named("order_uid", orderID))
-	if err != nil {
-		log.Println("failed to delete old items:", err)
-		return
+	var errs validation.Errors
+	errs.UUID("order_uid", ev.OrderUID)
+	if !ev.Status.Valid() {
+		errs.Add("status", validation.CodeFormat, "unknown order status %q", ev.Status)
 	}
-	for _, it := range order.Items {
-		ridUUID, err := uuid.Parse(it.Rid)
-		if err != nil {
-			log.Println("invalid item.rid:", err)
-			return
-		}
-		_, err = tx.Exec(`
-			INSERT INTO items (
-				id, order_uid, chrt_id, track_number, price, rid,
-				name, sale, size, total_price, nm_id, brand, status
-			) VALUES (
-				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
-			)`,
-			sql.Named("id", uuid.New()),
-			sql.Named("order_uid", orderID),
-			sql.Named("chrt_id", it.ChrtID),
-			sql.Named("track_number", it.TrackNumber),
-			sql.Named("price", it.Price),
-			sql.Named("rid", ridUUID),
-			sql.Named("name", it.Name),
-			sql.Named("sale", it.Sale),
-			sql.Named("size", it.Size),
-			sql.Named("total_price", it.TotalPrice),
-			sql.Named("nm_id", it.NmID),
-			sql.Named("brand", it.Brand),
-			sql.Named("status", it.Status),
-		)
-		if err != nil {
-			log.Println("failed to insert item:", err)
-			return
-		}
+	if len(errs) > 0 {
+		return &ingestError{Stage: dlq.StageValidate, Err: errs}
 	}
+	// ключ кэша - канонический UUID, как у заказов из processKafkaMessage
+	ev.OrderUID = uuid.MustParse(ev.OrderUID).String()
 
-	if err = tx.Commit(); err != nil {
-		log.Println("failed to commit tx:", err)
-		return
+	err := a.Retry.do(ctx, func() error {
+		return a.changeStatus(ctx, ev.OrderUID, ev.Status, sourceOf(msg), repository.AnyVersion)
+	})
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case apperr.KindOf(err) == apperr.Conflict:
+		return &ingestError{Stage: dlq.StageValidate, Err: err}
+	default:
+		return &ingestError{Stage: dlq.StagePersist, Err: err}
 	}
-
-	// обновляем кэш
-	a.Cache.Put(orderID.String(), msg)
-	log.Printf("Order %s saved to DB and cache", orderID)
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/apperr"
+)
+
+// errorResponse - тело ответа для любой ошибки API.
+type errorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeError переводит ошибку в HTTP-статус и JSON-тело. Внутренние ошибки
+// логируются, клиент видит только их код.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	kind := apperr.KindOf(err)
+	if kind == apperr.Internal && (isTransient(err) || errors.Is(err, context.DeadlineExceeded)) {
+		err = apperr.Wrap(apperr.Unavailable, err, "storage temporarily unavailable")
+		kind = apperr.Unavailable
+	}
+
+	status := http.StatusInternalServerError
+	switch kind {
+	case apperr.NotFound:
+		status = http.StatusNotFound
+	case apperr.Invalid:
+		status = http.StatusBadRequest
+	case apperr.Unavailable:
+		status = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", "1")
+	case apperr.Unauthenticated:
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	case apperr.Conflict:
+		status = http.StatusConflict
+	case apperr.FailedPrecondition:
+		status = http.StatusPreconditionFailed
+	}
+	if status >= http.StatusInternalServerError {
+		loggerFrom(r.Context()).Error("request failed", "path", r.URL.Path, "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{
+		Code:      kind.String(),
+		Message:   apperr.MessageOf(err),
+		RequestID: requestID(r.Context()),
+	})
+}
+
+// checkUUID проверяет идентификатор из запроса и возвращает его в каноническом
+// виде: в нижнем регистре, как он хранится в БД и используется ключом кэша.
+func checkUUID(name, value string) (string, error) {
+	if value == "" {
+		return "", apperr.New(apperr.Invalid, "missing %s", name)
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return "", apperr.New(apperr.Invalid, "%s must be a UUID", name)
+	}
+	return id.String(), nil
+}
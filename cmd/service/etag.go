@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"order-service/internal/apperr"
+	"order-service/internal/repository"
+)
+
+// versionOf достаёт номер версии из канонического JSON заказа.
+func versionOf(orderJSON []byte) int {
+	var v struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(orderJSON, &v)
+	return v.Version
+}
+
+// etag - сильный ETag заказа: его версия в кавычках.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion разбирает If-Match. Без заголовка или с "*" возвращает
+// repository.AnyVersion - изменение без условия. Поддерживается один ETag,
+// выданный etag.
+func ifMatchVersion(r *http.Request) (int, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return repository.AnyVersion, nil
+	}
+	s, ok := strings.CutPrefix(h, `"`)
+	if ok {
+		s, ok = strings.CutSuffix(s, `"`)
+	}
+	n, err := strconv.Atoi(s)
+	if !ok || err != nil || n < 0 {
+		return 0, apperr.New(apperr.Invalid, `If-Match must be a single order ETag like "3"`)
+	}
+	return n, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// consumerStatus отражает состояние Kafka-консьюмера для health-эндпоинтов.
+type consumerStatus struct {
+	mu         sync.Mutex
+	state      string
+	partitions map[string][]int32
+	lastErr    string
+	lastErrAt  time.Time
+}
+
+const (
+	consumerConnecting = "connecting"
+	consumerConnected  = "connected"
+	consumerStopped    = "stopped"
+)
+
+func (s *consumerStatus) setState(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if state != consumerConnected {
+		s.partitions = nil
+	}
+}
+
+func (s *consumerStatus) setClaims(claims map[string][]int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partitions = claims
+}
+
+func (s *consumerStatus) setError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err.Error()
+	s.lastErrAt = time.Now()
+}
+
+type checkResult struct {
+	Status     string             `json:"status"`
+	Error      string             `json:"error,omitempty"`
+	LatencyMS  *int64             `json:"latency_ms,omitempty"`
+	State      string             `json:"state,omitempty"`
+	Partitions map[string][]int32 `json:"partitions,omitempty"`
+	LastError  string             `json:"last_error,omitempty"`
+	Progress   string             `json:"progress,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+func (a *App) checkDatabase(ctx context.Context) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := a.DB.PingContext(ctx)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		return checkResult{Status: statusFail, Error: err.Error(), LatencyMS: &latency}
+	}
+	return checkResult{Status: statusOK, LatencyMS: &latency}
+}
+
+func (a *App) checkKafka() checkResult {
+	s := &a.consumer
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := checkResult{Status: statusOK, State: s.state, Partitions: s.partitions}
+	if res.State == "" {
+		res.State = "not started"
+	}
+	if s.lastErr != "" {
+		res.LastError = fmt.Sprintf("%s (%s ago)", s.lastErr, time.Since(s.lastErrAt).Round(time.Second))
+	}
+	if s.state != consumerConnected {
+		res.Status = statusFail
+	}
+	return res
+}
+
+func (a *App) checkWarmup() checkResult {
+	if !a.warmup.done.Load() {
+		return checkResult{Status: statusFail, State: "in progress", Progress: a.warmup.progress()}
+	}
+	return checkResult{Status: statusOK, State: "done", Progress: a.warmup.progress()}
+}
+
+// healthzHandler - liveness: процесс жив, пока консьюмер не остановился сам по себе.
+// Недоступность БД или Kafka не повод перезапускать процесс, поэтому они
+// показываются в ответе, но не влияют на статус.
+func (a *App) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	kafka := a.checkKafka()
+	resp := healthResponse{
+		Status: statusOK,
+		Checks: map[string]checkResult{
+			"kafka": kafka,
+		},
+	}
+	if kafka.State == consumerStopped {
+		resp.Status = statusFail
+	}
+	writeHealth(w, resp)
+}
+
+// readyzHandler - readiness: БД отвечает, консьюмер в группе, прогрев кэша завершён.
+func (a *App) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{
+		Status: statusOK,
+		Checks: map[string]checkResult{
+			"database":     a.checkDatabase(r.Context()),
+			"kafka":        a.checkKafka(),
+			"cache_warmup": a.checkWarmup(),
+		},
+	}
+	for _, c := range resp.Checks {
+		if c.Status != statusOK {
+			resp.Status = statusFail
+		}
+	}
+	writeHealth(w, resp)
+}
+
+func writeHealth(w http.ResponseWriter, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status != statusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"order-service/internal/apperr"
+	"order-service/internal/model"
+)
+
+type historyResponse struct {
+	OrderUID string          `json:"order_uid"`
+	Versions []model.Version `json:"versions"`
+}
+
+// orderHistoryHandler отдаёт все версии заказа с изменениями, без самих заказов.
+// GET /order/{id}/history
+func (a *App) orderHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := checkUUID("order id", r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	versions, err := a.Orders.History(r.Context(), orderID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if len(versions) == 0 {
+		// заказы, записанные до появления истории, версий не имеют
+		if _, err := a.Orders.Get(r.Context(), orderID); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(historyResponse{OrderUID: orderID, Versions: versions})
+}
+
+// orderVersionHandler отдаёт одну версию заказа целиком.
+// GET /order/{id}/versions/{n}
+func (a *App) orderVersionHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := checkUUID("order id", r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 1 {
+		writeError(w, r, apperr.New(apperr.Invalid, "version must be a positive integer"))
+		return
+	}
+
+	v, err := a.Orders.Version(r.Context(), orderID, n)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/invalidation"
+	"order-service/internal/repository"
+)
+
+// newInstanceID возвращает идентификатор экземпляра, уникальный между рестартами.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%s", host, uuid.NewString()[:8])
+}
+
+// publishInvalidation сообщает остальным экземплярам, что заказ записан в БД.
+// Ошибка не фатальна: заказ уже сохранён, а чужие копии устареют по TTL.
+func (a *App) publishInvalidation(ctx context.Context, orderUID string, version int) {
+	if a.Invalidation == nil {
+		return
+	}
+	ev := invalidation.Event{OrderUID: orderUID, Version: int64(version), Origin: a.InstanceID}
+	if err := a.Invalidation.Publish(ctx, ev); err != nil {
+		loggerFrom(ctx).Warn("Failed to publish cache invalidation", "error", err)
+	}
+}
+
+// applyInvalidation сбрасывает локальную копию заказа, записанного другим
+// экземпляром. Если заказ был в кэше, он сразу перечитывается из БД, чтобы
+// горячие заказы не давали всплеска промахов. Событие, запоздавшее
+// относительно закэшированной версии, пропускается.
+func (a *App) applyInvalidation(ctx context.Context, ev invalidation.Event) {
+	if ev.Origin == a.InstanceID {
+		return
+	}
+	// версия 0 - отправитель её не знает, такое событие применяем всегда
+	if cached, ok := a.Cache.Peek(ev.OrderUID); ok && ev.Version > 0 && int64(versionOf(cached)) >= ev.Version {
+		cacheInvalidations.WithLabelValues("stale").Inc()
+		return
+	}
+	if a.Missing != nil {
+		a.Missing.Delete(ev.OrderUID)
+	}
+	a.forgetLoad(ev.OrderUID)
+	if !a.Cache.Delete(ev.OrderUID) {
+		cacheInvalidations.WithLabelValues("absent").Inc()
+		return
+	}
+
+	_, err := a.loadOrderJSON(ctx, ev.OrderUID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		cacheInvalidations.WithLabelValues("evicted").Inc()
+		slog.Warn("Failed to refresh invalidated order", "order_uid", ev.OrderUID, "error", err)
+		return
+	}
+	cacheInvalidations.WithLabelValues("refreshed").Inc()
+}
+
+const invalidationRetryDelay = 5 * time.Second
+
+// runInvalidation применяет события других экземпляров, пока не отменён ctx.
+// Оборвавшаяся подписка восстанавливается: без неё транспорт не может и
+// публиковать события.
+func (a *App) runInvalidation(ctx context.Context) {
+	if a.Invalidation == nil {
+		return
+	}
+	for {
+		err := a.Invalidation.Subscribe(ctx, func(ev invalidation.Event) {
+			a.applyInvalidation(ctx, ev)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("Cache invalidation subscription failed, retrying", "error", err, "delay", invalidationRetryDelay)
+		select {
+		case <-time.After(invalidationRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"order-service/internal/apperr"
+	"order-service/internal/model"
+	"order-service/internal/repository"
+)
+
+type listOrdersResponse struct {
+	// заказы в каноническом виде model.Encode, как в GET /order/{id}
+	Orders     []json.RawMessage `json:"orders"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// listOrdersHandler отдаёт страницу заказов с фильтрами.
+// GET /orders?customer_id=&track_number=&delivery_service=&entry=&payment_provider=&brand=
+//
+//	&status=&created_from=&created_to=&sort=-date_created&limit=&cursor=
+func (a *App) listOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	orders, err := a.Orders.List(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := listOrdersResponse{Orders: make([]json.RawMessage, 0, len(orders))}
+	for _, order := range orders {
+		data, err := model.Encode(order)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		resp.Orders = append(resp.Orders, data)
+	}
+	// полная страница - возможно, есть следующая
+	if len(orders) > 0 && len(orders) == limitOrDefault(q.Limit) {
+		resp.NextCursor = repository.CursorOf(orders[len(orders)-1]).Encode()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func parseListQuery(v url.Values) (repository.ListQuery, error) {
+	q := repository.ListQuery{
+		CustomerID:      v.Get("customer_id"),
+		TrackNumber:     v.Get("track_number"),
+		DeliveryService: v.Get("delivery_service"),
+		Entry:           v.Get("entry"),
+		PaymentProvider: v.Get("payment_provider"),
+		Brand:           v.Get("brand"),
+		Desc:            true,
+	}
+
+	if s := v.Get("status"); s != "" {
+		st, err := model.ParseStatus(s)
+		if err != nil {
+			return q, apperr.Wrap(apperr.Invalid, err, err.Error())
+		}
+		q.Status = st
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > repository.MaxListLimit {
+			return q, apperr.New(apperr.Invalid, "limit must be between 1 and %d", repository.MaxListLimit)
+		}
+		q.Limit = n
+	}
+	switch v.Get("sort") {
+	case "", "-date_created":
+	case "date_created":
+		q.Desc = false
+	default:
+		return q, apperr.New(apperr.Invalid, "sort must be date_created or -date_created")
+	}
+	if s := v.Get("cursor"); s != "" {
+		c, err := repository.DecodeCursor(s)
+		if err != nil {
+			return q, err
+		}
+		q.After = c
+	}
+
+	var err error
+	if q.CreatedFrom, err = parseTime(v, "created_from"); err != nil {
+		return q, err
+	}
+	if q.CreatedTo, err = parseTime(v, "created_to"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func parseTime(v url.Values, key string) (time.Time, error) {
+	s := v.Get(key)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, apperr.New(apperr.Invalid, "%s must be an RFC 3339 timestamp", key)
+	}
+	return t, nil
+}
+
+func limitOrDefault(n int) int {
+	if n <= 0 {
+		return repository.DefaultListLimit
+	}
+	return n
+}
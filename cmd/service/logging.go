@@ -1,27 +1,84 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+const requestIDHeader = "X-Request-ID"
+
+type loggerKey struct{}
+
+// withLogger кладёт в контекст логгер с уже привязанными полями запроса или сообщения.
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// loggerFrom возвращает логгер из контекста или логгер по умолчанию.
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+type requestIDKey struct{}
+
+// requestID возвращает идентификатор текущего HTTP-запроса.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newLogger создаёт корневой логгер сервиса.
+func newLogger(level, format string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+// fatal пишет ошибку и завершает процесс.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		// берём ID от вызывающей стороны, если он есть, иначе генерируем свой
+		reqID := r.Header.Get(requestIDHeader)
+		if reqID == "" || len(reqID) > 128 {
+			reqID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, reqID)
+
+		logger := slog.Default().With("request_id", reqID)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, reqID)
+		r = r.WithContext(withLogger(ctx, logger))
+
 		// обертка, чтобы поймать статус код
 		rw := &responseWriter{ResponseWriter: w, status: 200}
 
 		next.ServeHTTP(rw, r)
 
-		duration := time.Since(start)
-
-		log.Printf("%s %s %d %s",
-			r.Method,
-			r.URL.Path,
-			rw.status,
-			duration,
+		logger.Info("http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rw.status,
+			"duration", time.Since(start),
 		)
 	})
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"order-service/internal/apperr"
+	"order-service/internal/repository"
+)
+
+// lookupOrdersHandler находит заказы по полю key, значение которого берётся
+// из параметра пути param, и отдаёт их JSON-массивом. Каждый заказ
+// сериализуется и кэшируется так же, как в getOrderHandler.
+func (a *App) lookupOrdersHandler(key repository.LookupKey, param string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value := r.PathValue(param)
+		if value == "" {
+			writeError(w, r, apperr.New(apperr.Invalid, "missing %s", param))
+			return
+		}
+		if key != repository.ByTrackNumber {
+			var err error
+			if value, err = checkUUID(string(key), value); err != nil {
+				writeError(w, r, err)
+				return
+			}
+		}
+
+		uids, err := a.Orders.FindOrderUIDs(r.Context(), key, value)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		orders := make([]json.RawMessage, 0, len(uids))
+		for _, uid := range uids {
+			data, err := a.orderJSON(r.Context(), uid)
+			if errors.Is(err, repository.ErrNotFound) {
+				// заказ удалили между поиском и чтением
+				continue
+			}
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			orders = append(orders, data)
+		}
+		if len(orders) == 0 {
+			writeError(w, r, apperr.New(apperr.NotFound, "no orders with %s %q", key, value))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(orders)
+	}
+}
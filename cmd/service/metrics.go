@@ -0,0 +1,127 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"order-service/internal/cache"
+)
+
+const metricsNamespace = "order_service"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	kafkaMessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "kafka_messages_consumed_total",
+		Help:      "Kafka messages read from the orders topic.",
+	}, []string{"topic", "partition"})
+
+	kafkaMessagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "kafka_messages_failed_total",
+		Help:      "Kafka messages that failed processing, by failure stage.",
+	}, []string{"topic", "partition", "stage"})
+
+	kafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages between the last consumed offset and the partition high watermark.",
+	}, []string{"topic", "partition"})
+
+	cacheNegativeHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "cache",
+		Name:      "negative_hits_total",
+		Help:      "Lookups answered from the cache of missing order IDs.",
+	})
+
+	cacheCoalescedLoads = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "cache",
+		Name:      "coalesced_loads_total",
+		Help:      "Cache misses served by a database load already in flight for the same order.",
+	})
+
+	cacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "cache",
+		Name:      "invalidations_total",
+		Help:      "Invalidation events from other instances: refreshed, evicted (refresh failed), absent locally or stale (local copy is as new).",
+	}, []string{"result"})
+
+	ingestSkippedStale = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingest_skipped_stale_total",
+		Help:      "Orders not written because a newer version (by updated_at) is already stored.",
+	})
+
+	ingestTxDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingest_tx_duration_seconds",
+		Help:      "Duration of a single order upsert transaction.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+)
+
+// registerRuntimeMetrics регистрирует метрики, которые снимаются с живых объектов приложения.
+func registerRuntimeMetrics(db *sql.DB, c cache.Cache) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "orders"))
+
+	cacheStat := func(name, help string, valueType prometheus.ValueType, f func(cache.Stats) float64) {
+		opts := prometheus.Opts{Namespace: metricsNamespace, Subsystem: "cache", Name: name, Help: help}
+		value := func() float64 { return f(c.Stats()) }
+		if valueType == prometheus.CounterValue {
+			prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts(opts), value))
+		} else {
+			prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts), value))
+		}
+	}
+	cacheStat("hits_total", "Cache lookups that found the order.", prometheus.CounterValue,
+		func(s cache.Stats) float64 { return float64(s.Hits) })
+	cacheStat("misses_total", "Cache lookups that missed.", prometheus.CounterValue,
+		func(s cache.Stats) float64 { return float64(s.Misses) })
+	cacheStat("evictions_total", "Entries evicted to stay within capacity.", prometheus.CounterValue,
+		func(s cache.Stats) float64 { return float64(s.Evictions) })
+	cacheStat("expirations_total", "Entries removed after their TTL expired.", prometheus.CounterValue,
+		func(s cache.Stats) float64 { return float64(s.Expirations) })
+	cacheStat("rejections_total", "New entries rejected by the admission policy.", prometheus.CounterValue,
+		func(s cache.Stats) float64 { return float64(s.Rejections) })
+	cacheStat("entries", "Orders currently cached.", prometheus.GaugeValue,
+		func(s cache.Stats) float64 { return float64(s.Len) })
+	cacheStat("bytes", "Total size of cached keys and values.", prometheus.GaugeValue,
+		func(s cache.Stats) float64 { return float64(s.Bytes) })
+}
+
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: 200}
+
+		next.ServeHTTP(rw, r)
+
+		// ServeMux записывает сработавший шаблон в r.Pattern
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(rw.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+func partitionLabel(p int32) string {
+	return strconv.Itoa(int(p))
+}
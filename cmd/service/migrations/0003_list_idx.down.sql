@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_date_created;
+DROP INDEX IF EXISTS idx_orders_customer_created;
+DROP INDEX IF EXISTS idx_orders_track_created;
+DROP INDEX IF EXISTS idx_orders_delivery_svc_created;
+DROP INDEX IF EXISTS idx_orders_entry_created;
+DROP INDEX IF EXISTS idx_payments_provider;
+DROP INDEX IF EXISTS idx_items_brand;
@@ -0,0 +1,8 @@
+-- keyset-пагинация GET /orders: (date_created, order_uid)
+CREATE INDEX idx_orders_date_created          ON orders     USING btree(date_created, order_uid);
+CREATE INDEX idx_orders_customer_created      ON orders     USING btree(customer_id, date_created, order_uid);
+CREATE INDEX idx_orders_track_created         ON orders     USING btree(track_number, date_created, order_uid);
+CREATE INDEX idx_orders_delivery_svc_created  ON orders     USING btree(delivery_service, date_created, order_uid);
+CREATE INDEX idx_orders_entry_created         ON orders     USING btree(entry, date_created, order_uid);
+CREATE INDEX idx_payments_provider            ON payments   USING btree(provider, order_uid);
+CREATE INDEX idx_items_brand                  ON items      USING btree(brand, order_uid);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payments_transaction;
+DROP INDEX IF EXISTS idx_items_rid;
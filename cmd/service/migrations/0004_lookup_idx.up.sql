@@ -0,0 +1,4 @@
+-- поиск заказа по транзакции и rid позиции;
+-- по трек-номеру ищем через idx_orders_track_created из 0003
+CREATE INDEX idx_payments_transaction ON payments USING btree(transaction);
+CREATE INDEX idx_items_rid            ON items    USING btree(rid);
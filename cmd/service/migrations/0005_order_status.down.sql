@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders DROP COLUMN IF EXISTS status_updated_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
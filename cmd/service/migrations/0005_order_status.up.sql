@@ -0,0 +1,9 @@
+-- статус заказа ведёт сервис; существующие заказы считаем созданными
+ALTER TABLE orders
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'created',
+    ADD COLUMN status_updated_at TIMESTAMPTZ;
+
+ALTER TABLE orders
+    ADD CONSTRAINT chk_orders_status CHECK (status IN (
+        'created', 'paid', 'assembling', 'shipped', 'delivered', 'cancelled', 'returned'
+    ));
@@ -0,0 +1,13 @@
+-- все принятые версии заказа; история переживает удаление заказа.
+-- data и changes хранятся как json, а не jsonb, чтобы отдавать байты как есть
+CREATE TABLE order_versions (
+    order_uid        UUID        NOT NULL,
+    version          INTEGER     NOT NULL,
+    received_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    source_topic     VARCHAR(255),
+    source_partition INTEGER,
+    source_offset    BIGINT,
+    data             JSON        NOT NULL,
+    changes          JSON        NOT NULL,
+    PRIMARY KEY (order_uid, version)
+);
@@ -0,0 +1,10 @@
+-- version - номер последней версии из order_versions, updated_at - время
+-- изменения у источника, по нему upsert не даёт откатиться к старой версии
+ALTER TABLE orders
+    ADD COLUMN version    INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN updated_at TIMESTAMPTZ;
+
+UPDATE orders o
+SET version = v.version
+FROM (SELECT order_uid, max(version) AS version FROM order_versions GROUP BY order_uid) v
+WHERE v.order_uid = o.order_uid;
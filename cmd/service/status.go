@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"order-service/internal/apperr"
+	"order-service/internal/model"
+)
+
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+// updateStatusHandler меняет статус заказа и отдаёт заказ после изменения.
+// С If-Match изменение применяется, только если версия заказа совпадает.
+// PATCH /order/{id}/status {"status": "paid"}
+func (a *App) updateStatusHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := checkUUID("order id", r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ifVersion, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var req statusRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOrderBodyBytes)).Decode(&req); err != nil {
+		writeError(w, r, apperr.New(apperr.Invalid, "invalid request JSON: %v", err))
+		return
+	}
+	to, err := model.ParseStatus(req.Status)
+	if err != nil {
+		writeError(w, r, apperr.Wrap(apperr.Invalid, err, err.Error()))
+		return
+	}
+
+	if err := a.changeStatus(r.Context(), orderID, to, nil, ifVersion); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	orderJson, err := a.orderJSON(r.Context(), orderID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(versionOf(orderJson)))
+	w.Write(orderJson)
+}
+
+// changeStatus применяет переход статуса, перечитывает заказ в кэш и
+// сбрасывает его на остальных экземплярах. src - nil для изменений через API,
+// ifVersion - repository.AnyVersion для изменений без условия.
+func (a *App) changeStatus(ctx context.Context, orderID string, to model.Status, src *model.Source, ifVersion int) error {
+	from, err := a.Orders.UpdateStatus(ctx, orderID, to, src, ifVersion)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	a.forgetLoad(orderID)
+	a.Cache.Delete(orderID)
+	version := 0
+	if data, err := a.loadOrderJSON(ctx, orderID); err == nil {
+		version = versionOf(data)
+	}
+	a.publishInvalidation(ctx, orderID, version)
+	loggerFrom(ctx).Info("Order status changed", "order_uid", orderID, "from", from, "to", to)
+	return nil
+}
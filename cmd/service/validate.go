@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"order-service/internal/apperr"
+	"order-service/internal/model"
+	"order-service/internal/validation"
+)
+
+const maxOrderBodyBytes = 1 << 20
+
+type validationResponse struct {
+	Valid  bool              `json:"valid"`
+	Errors validation.Errors `json:"errors"`
+}
+
+// validateOrderHandler проверяет присланный заказ теми же правилами, что и консьюмер,
+// ничего не сохраняя.
+func (a *App) validateOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var order model.Order
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOrderBodyBytes)).Decode(&order); err != nil {
+		writeError(w, r, apperr.New(apperr.Invalid, "invalid order JSON: %v", err))
+		return
+	}
+
+	resp := validationResponse{Valid: true, Errors: validation.Errors{}}
+	status := http.StatusOK
+	if errs := order.Validate(); len(errs) > 0 {
+		resp = validationResponse{Valid: false, Errors: errs}
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"order-service/internal/cache"
+	"order-service/internal/model"
+	"order-service/internal/repository"
+)
+
+// Стратегии прогрева кэша.
+const (
+	warmupRecent  = "recent"
+	warmupPopular = "popular"
+	warmupNone    = "none"
+)
+
+// warmupState - прогресс фонового прогрева для /readyz и метрик.
+type warmupState struct {
+	done    atomic.Bool // первый прогрев (или восстановление снимка) завершён
+	running atomic.Bool
+	loaded  atomic.Int64
+	target  atomic.Int64
+}
+
+func (s *warmupState) progress() string {
+	return fmt.Sprintf("%d/%d", s.loaded.Load(), s.target.Load())
+}
+
+// errWarmupRunning - прогрев уже идёт, второй параллельно не запускается.
+var errWarmupRunning = errors.New("cache warmup is already running")
+
+// warmupCache заполняет кэш по стратегии страницами по WarmupPageSize
+// заказов. Запускается в фоне: сервер и консьюмер работают, пока идёт прогрев.
+func (a *App) warmupCache(ctx context.Context, strategy string) error {
+	if !a.warmup.running.CompareAndSwap(false, true) {
+		return errWarmupRunning
+	}
+	defer a.warmup.running.Store(false)
+	defer a.warmup.done.Store(true)
+	a.warmup.loaded.Store(0)
+	a.warmup.target.Store(0)
+
+	start := time.Now()
+	var err error
+	switch strategy {
+	case warmupRecent:
+		err = a.warmupRecent(ctx)
+	case warmupPopular:
+		err = a.warmupPopular(ctx)
+	case warmupNone:
+		slog.Info("Cache warmup disabled")
+		return nil
+	default:
+		return fmt.Errorf("unknown warmup strategy %q", strategy)
+	}
+	if err != nil {
+		return fmt.Errorf("Warmup query failed: %w", err)
+	}
+	slog.Info("Cache warmup complete", "strategy", strategy,
+		"orders", a.warmup.loaded.Load(), "duration", time.Since(start))
+	return nil
+}
+
+// warmupRecent загружает последние по date_created заказы. Страницы читаются
+// от старых к новым, начиная с limit-го с конца заказа, чтобы самые новые
+// попали в кэш последними и вытеснялись позже всех.
+func (a *App) warmupRecent(ctx context.Context) error {
+	limit := a.Cache.Capacity()
+	a.warmup.target.Store(int64(limit))
+
+	after, err := a.Orders.NthNewest(ctx, limit+1)
+	if err != nil {
+		return err
+	}
+	q := repository.ListQuery{After: after}
+	for loaded := 0; loaded < limit; {
+		q.Limit = min(a.WarmupPageSize, limit-loaded)
+		page, err := a.Orders.List(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, order := range page {
+			if err := a.warmupPut(order); err != nil {
+				return err
+			}
+		}
+		loaded += len(page)
+		a.logWarmupProgress()
+		if len(page) < q.Limit {
+			break
+		}
+		q.After = repository.CursorOf(page[len(page)-1])
+	}
+	return nil
+}
+
+// warmupPopular загружает самые запрашиваемые заказы по журналу обращений.
+func (a *App) warmupPopular(ctx context.Context) error {
+	if a.AccessLog == nil {
+		return errors.New("access log is not configured")
+	}
+	ids := a.AccessLog.Top(a.Cache.Capacity())
+	a.warmup.target.Store(int64(len(ids)))
+
+	// самые популярные кладём последними: их LRU вытеснит позже всех
+	slices.Reverse(ids)
+	for i, id := range ids {
+		order, err := a.Orders.Get(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			// заказ удалили после того, как его запрашивали
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := a.warmupPut(order); err != nil {
+			return err
+		}
+		if (i+1)%a.WarmupPageSize == 0 {
+			a.logWarmupProgress()
+		}
+	}
+	a.logWarmupProgress()
+	return nil
+}
+
+func (a *App) warmupPut(order model.Order) error {
+	data, err := model.Encode(order)
+	if err != nil {
+		return err
+	}
+	a.cacheOrder(order.OrderUID, data)
+	a.warmup.loaded.Add(1)
+	return nil
+}
+
+func (a *App) logWarmupProgress() {
+	slog.Info("Cache warmup progress", "loaded", a.warmup.progress())
+}
+
+// restoreSnapshot загружает кэш из снимка и сообщает, удалось ли это.
+// Если снимка нет, он повреждён или все записи в нём устарели (по cache.ttl
+// или snapshot_max_age), кэш прогревается из БД как обычно.
+func (a *App) restoreSnapshot(path string, maxAge time.Duration) bool {
+	s, ok := a.Cache.(cache.Snapshotter)
+	if path == "" || !ok {
+		return false
+	}
+	n, err := cache.LoadSnapshot(s, path, maxAge)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.Info("No cache snapshot, falling back to warmup", "path", path)
+		return false
+	}
+	if err != nil {
+		slog.Warn("Cache snapshot unusable, falling back to warmup", "path", path, "error", err)
+		return false
+	}
+	if n == 0 {
+		slog.Info("Cache snapshot has no usable entries, falling back to warmup", "path", path)
+		return false
+	}
+	a.warmup.loaded.Store(int64(n))
+	a.warmup.target.Store(int64(n))
+	a.warmup.done.Store(true)
+	slog.Info("Cache restored from snapshot", "path", path, "orders", n)
+	return true
+}
+
+// saveSnapshot сохраняет кэш при остановке сервиса.
+func (a *App) saveSnapshot(path string) {
+	s, ok := a.Cache.(cache.Snapshotter)
+	if path == "" || !ok {
+		return
+	}
+	if err := cache.SaveSnapshot(s, path); err != nil {
+		slog.Error("Failed to save cache snapshot", "path", path, "error", err)
+		return
+	}
+	slog.Info("Cache snapshot saved", "path", path, "orders", a.Cache.Stats().Len)
+}
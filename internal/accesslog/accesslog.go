@@ -0,0 +1,160 @@
+// Package accesslog считает обращения к заказам и сохраняет счётчики в файл,
+// чтобы после рестарта прогреть кэш самыми запрашиваемыми заказами.
+package accesslog
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log - счётчики обращений по order_uid. Число ключей ограничено: при
+// переполнении все счётчики делятся пополам, а обнулившиеся удаляются,
+// так что давно не запрашиваемые заказы постепенно вытесняются.
+type Log struct {
+	path    string
+	maxKeys int
+
+	mu     sync.Mutex
+	counts map[string]uint64
+	dirty  bool
+}
+
+// Open загружает счётчики из path. Отсутствующий файл - не ошибка.
+func Open(path string, maxKeys int) (*Log, error) {
+	l := &Log{path: path, maxKeys: maxKeys, counts: make(map[string]uint64)}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		id, count, ok := strings.Cut(sc.Text(), " ")
+		n, err := strconv.ParseUint(count, 10, 64)
+		if !ok || id == "" || err != nil {
+			return nil, fmt.Errorf("%s:%d: malformed line", path, line)
+		}
+		l.counts[id] = n
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	l.shrink()
+	return l, nil
+}
+
+// Record учитывает одно обращение к заказу.
+func (l *Log) Record(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.counts[id]; !ok && len(l.counts) >= l.maxKeys {
+		l.shrink()
+	}
+	l.counts[id]++
+	l.dirty = true
+}
+
+// shrink старит счётчики, пока ключей не станет меньше maxKeys.
+func (l *Log) shrink() {
+	for len(l.counts) >= l.maxKeys {
+		for id, n := range l.counts {
+			if n /= 2; n == 0 {
+				delete(l.counts, id)
+			} else {
+				l.counts[id] = n
+			}
+		}
+	}
+}
+
+// Top возвращает до n самых запрашиваемых order_uid по убыванию числа обращений.
+func (l *Log) Top(n int) []string {
+	l.mu.Lock()
+	ids := make([]string, 0, len(l.counts))
+	for id := range l.counts {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return cmp.Or(cmp.Compare(l.counts[b], l.counts[a]), strings.Compare(a, b))
+	})
+	l.mu.Unlock()
+
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// Save записывает счётчики в файл, если они изменились с прошлого сохранения.
+// Файл заменяется атомарно через переименование временного.
+func (l *Log) Save() error {
+	l.mu.Lock()
+	if !l.dirty {
+		l.mu.Unlock()
+		return nil
+	}
+	var b strings.Builder
+	for id, n := range l.counts {
+		fmt.Fprintf(&b, "%s %d\n", id, n)
+	}
+	l.dirty = false
+	l.mu.Unlock()
+
+	if err := writeFile(l.path, b.String()); err != nil {
+		l.mu.Lock()
+		l.dirty = true
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func writeFile(path, data string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Run сохраняет счётчики раз в interval, пока не отменён ctx.
+// Финальное сохранение при остановке - забота вызывающего.
+func (l *Log) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Save(); err != nil {
+				slog.Error("Failed to save access log", "path", l.path, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package apperr описывает доменные ошибки сервиса, которые транспорт
+// (HTTP, Kafka) переводит в свои коды ответа.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind - категория ошибки.
+type Kind int
+
+const (
+	// Internal - непредвиденная ошибка; детали не показываются клиенту.
+	Internal Kind = iota
+	// NotFound - запрошенного объекта нет.
+	NotFound
+	// Invalid - запрос или данные некорректны, повтор не поможет.
+	Invalid
+	// Unavailable - зависимость временно недоступна, запрос можно повторить.
+	Unavailable
+	// Unauthenticated - нет или неверны учётные данные.
+	Unauthenticated
+	// Conflict - запрос противоречит текущему состоянию объекта.
+	Conflict
+	// FailedPrecondition - не выполнено условие запроса (например, If-Match).
+	FailedPrecondition
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NotFound:
+		return "not_found"
+	case Invalid:
+		return "invalid_argument"
+	case Unavailable:
+		return "unavailable"
+	case Unauthenticated:
+		return "unauthenticated"
+	case Conflict:
+		return "conflict"
+	case FailedPrecondition:
+		return "failed_precondition"
+	default:
+		return "internal"
+	}
+}
+
+// Error - ошибка с категорией и сообщением для клиента.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// New создаёт ошибку категории kind.
+func New(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap добавляет к err категорию и сообщение для клиента.
+func Wrap(kind Kind, err error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// KindOf возвращает категорию первой *Error в цепочке err или Internal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Internal
+}
+
+// MessageOf возвращает сообщение для клиента. Для Internal оно не раскрывает деталей.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Kind != Internal {
+		return e.Message
+	}
+	return "internal error"
+}
@@ -0,0 +1,86 @@
+// Package cache содержит in-memory кэши сериализованных заказов.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Cache - кэш сериализованных заказов по order_uid. Реализации безопасны
+// для конкурентного использования.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	// Peek - Get без учёта в статистике и порядке вытеснения.
+	Peek(key string) ([]byte, bool)
+	Put(key string, value []byte)
+	// PutIfNewer кладёт значение, если ключа нет в кэше или newer(old, value)
+	// истинно, и сообщает, записано ли оно. Сравнение и запись выполняются
+	// под одной блокировкой.
+	PutIfNewer(key string, value []byte, newer func(old, value []byte) bool) bool
+	// Delete удаляет запись и сообщает, была ли она в кэше.
+	Delete(key string) bool
+	// Capacity - максимальное число записей.
+	Capacity() int
+	Stats() Stats
+	// Keys возвращает до limit живых записей с ключами больше after по возрастанию ключа.
+	Keys(after string, limit int) []KeyInfo
+	// Flush удаляет все записи и возвращает их число.
+	Flush() int
+	// RunJanitor фоново удаляет устаревшие записи, пока не отменён ctx.
+	RunJanitor(ctx context.Context, interval time.Duration)
+}
+
+// Stats - накопленная статистика кэша.
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	// Rejections - новые записи, не прошедшие admission-политику.
+	Rejections uint64
+	Len        int
+	Bytes      int64
+}
+
+// KeyInfo описывает запись кэша без значения.
+type KeyInfo struct {
+	Key      string
+	Size     int64
+	StoredAt time.Time
+	// ExpiresAt - нулевое значение, если TTL не задан.
+	ExpiresAt time.Time
+}
+
+// Admission-политики для новых записей в заполненном кэше.
+const (
+	// AdmissionNone - всегда вытеснять самую давнюю запись (обычный LRU).
+	AdmissionNone = "none"
+	// AdmissionTinyLFU - вытеснять, только если новый ключ запрашивают
+	// чаще жертвы (частотный фильтр TinyLFU из W-TinyLFU).
+	AdmissionTinyLFU = "tinylfu"
+)
+
+// Options описывает кэш, который строит New.
+type Options struct {
+	Capacity int
+	MaxBytes int64
+	TTL      time.Duration
+	// Shards > 1 разбивает кэш на независимые сегменты со своими блокировками.
+	Shards    int
+	Admission string
+}
+
+// New возвращает простой LRUCache для одного сегмента без admission-политики
+// и ShardedCache в остальных случаях.
+func New(opts Options) (Cache, error) {
+	switch opts.Admission {
+	case "", AdmissionNone, AdmissionTinyLFU:
+	default:
+		return nil, fmt.Errorf("unknown cache admission policy %q", opts.Admission)
+	}
+	if opts.Shards <= 1 && (opts.Admission == "" || opts.Admission == AdmissionNone) {
+		return NewLRUCache(opts.Capacity, opts.MaxBytes, opts.TTL), nil
+	}
+	return NewShardedCache(opts), nil
+}
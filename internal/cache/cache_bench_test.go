@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+)
+
+// Бенчмарки сравнивают реализации кэша под параллельной нагрузкой:
+// ключи запрашиваются по закону Ципфа, промах дочитывает значение и кладёт
+// его в кэш, как это делает getOrderHandler. Кроме ns/op отчёт содержит
+// долю попаданий.
+//
+//	go test ./internal/cache -run '^$' -bench . -cpu 1,4,16
+const (
+	benchCapacity  = 10_000
+	benchKeys      = 100_000
+	benchValueSize = 2048
+	benchZipfS     = 1.1
+	benchWritePct  = 10
+)
+
+var benchNames = func() []string {
+	names := make([]string, benchKeys)
+	for i := range names {
+		names[i] = "order-" + strconv.Itoa(i)
+	}
+	return names
+}()
+
+func benchmarkCache(b *testing.B, opts Options) {
+	c, err := New(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	value := make([]byte, benchValueSize)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+		zipf := rand.NewZipf(r, benchZipfS, 1, benchKeys-1)
+		for pb.Next() {
+			key := benchNames[zipf.Uint64()]
+			if r.IntN(100) < benchWritePct {
+				c.Put(key, value)
+				continue
+			}
+			if _, ok := c.Get(key); !ok {
+				c.Put(key, value)
+			}
+		}
+	})
+	b.StopTimer()
+
+	st := c.Stats()
+	if total := st.Hits + st.Misses; total > 0 {
+		b.ReportMetric(float64(st.Hits)/float64(total), "hit-ratio")
+	}
+}
+
+func BenchmarkCacheLRU(b *testing.B) {
+	benchmarkCache(b, Options{Capacity: benchCapacity})
+}
+
+func BenchmarkCacheSharded16(b *testing.B) {
+	benchmarkCache(b, Options{Capacity: benchCapacity, Shards: 16})
+}
+
+func BenchmarkCacheSharded64(b *testing.B) {
+	benchmarkCache(b, Options{Capacity: benchCapacity, Shards: 64})
+}
+
+func BenchmarkCacheSharded16TinyLFU(b *testing.B) {
+	benchmarkCache(b, Options{Capacity: benchCapacity, Shards: 16, Admission: AdmissionTinyLFU})
+}
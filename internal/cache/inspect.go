@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"container/list"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (c *LRUCache) Peek(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		if en := el.Value.(*entry); !c.expired(en, time.Now()) {
+			return en.value, true
+		}
+	}
+	return nil, false
+}
+
+func (c *LRUCache) Keys(after string, limit int) []KeyInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var keys []KeyInfo
+	for key, el := range c.items {
+		en := el.Value.(*entry)
+		if key <= after || c.expired(en, now) {
+			continue
+		}
+		keys = append(keys, KeyInfo{Key: key, Size: en.size(), StoredAt: en.storedAt, ExpiresAt: en.expiresAt})
+	}
+	return firstKeys(keys, limit)
+}
+
+func (c *LRUCache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.evict.Len()
+	c.items = make(map[string]*list.Element)
+	c.evict.Init()
+	c.bytes = 0
+	return n
+}
+
+func (c *ShardedCache) Peek(key string) ([]byte, bool) {
+	return c.shards[c.shard(key)].Peek(key)
+}
+
+func (c *ShardedCache) Keys(after string, limit int) []KeyInfo {
+	var keys []KeyInfo
+	for _, s := range c.shards {
+		keys = append(keys, s.Keys(after, limit)...)
+	}
+	return firstKeys(keys, limit)
+}
+
+func (c *ShardedCache) Flush() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Flush()
+	}
+	return n
+}
+
+// firstKeys сортирует записи по ключу и оставляет первые limit.
+func firstKeys(keys []KeyInfo, limit int) []KeyInfo {
+	slices.SortFunc(keys, func(a, b KeyInfo) int { return strings.Compare(a.Key, b.Key) })
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
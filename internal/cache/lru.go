@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRUCache ограничен числом записей и, опционально, суммарным размером
+// значений в байтах. Записи с истёкшим TTL удаляются лениво при чтении
+// и фоново через RunJanitor.
+type LRUCache struct {
+	capacity int
+	maxBytes int64
+	ttl      time.Duration
+	mu       sync.Mutex
+	items    map[string]*list.Element
+	evict    *list.List
+	bytes    int64
+
+	// admit, если задан, решает, вытеснять ли victim ради новой записи candidate
+	admit func(candidate, victim string) bool
+
+	// счётчики для метрик, меняются под mu
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+	rejections  uint64
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	storedAt  time.Time
+	expiresAt time.Time
+}
+
+func (e *entry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// NewLRUCache создаёт кэш на cap записей. maxBytes ограничивает суммарный
+// размер ключей и значений, ttl - время жизни записи; нулевые значения
+// отключают соответствующее ограничение.
+func NewLRUCache(cap int, maxBytes int64, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		capacity: cap,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		evict:    list.New(),
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		en := el.Value.(*entry)
+		if c.expired(en, time.Now()) {
+			c.remove(el)
+			c.expirations++
+			c.misses++
+			return nil, false
+		}
+		c.evict.MoveToFront(el)
+		c.hits++
+		return en.value, true
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *LRUCache) Put(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(&entry{key: key, value: value, storedAt: time.Now()})
+}
+
+func (c *LRUCache) PutIfNewer(key string, value []byte, newer func(old, value []byte) bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		if en := el.Value.(*entry); !c.expired(en, time.Now()) && !newer(en.value, value) {
+			return false
+		}
+	}
+	c.put(&entry{key: key, value: value, storedAt: time.Now()})
+	return true
+}
+
+// put вставляет запись как самую свежую; вызывается под c.mu.
+func (c *LRUCache) put(en *entry) {
+	key := en.key
+	if c.ttl > 0 {
+		en.expiresAt = en.storedAt.Add(c.ttl)
+	}
+	if c.maxBytes > 0 && en.size() > c.maxBytes {
+		// значение больше всего кэша: не кэшируем и убираем старую версию
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+		return
+	}
+
+	if el, ok := c.items[key]; ok {
+		// обновляем
+		c.evict.MoveToFront(el)
+		c.bytes += en.size() - el.Value.(*entry).size()
+		el.Value = en
+	} else {
+		if c.admit != nil && c.overflows(en.size()) {
+			if victim := c.evict.Back(); victim != nil && !c.admit(key, victim.Value.(*entry).key) {
+				c.rejections++
+				return
+			}
+		}
+		// вставка нового
+		c.items[key] = c.evict.PushFront(en)
+		c.bytes += en.size()
+	}
+
+	// удалить старые, пока не уложимся в лимиты
+	for c.evict.Len() > c.capacity || c.maxBytes > 0 && c.bytes > c.maxBytes {
+		old := c.evict.Back()
+		if old == nil || old.Value.(*entry) == en {
+			break
+		}
+		c.remove(old)
+		c.evictions++
+	}
+}
+
+// Delete удаляет запись и сообщает, была ли она в кэше.
+func (c *LRUCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if ok {
+		c.remove(el)
+	}
+	return ok
+}
+
+// RunJanitor периодически удаляет записи с истёкшим TTL, пока не отменён ctx.
+func (c *LRUCache) RunJanitor(ctx context.Context, interval time.Duration) {
+	if c.ttl <= 0 || interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			c.removeExpired(now)
+		}
+	}
+}
+
+func (c *LRUCache) removeExpired(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.evict.Back(); el != nil; {
+		prev := el.Prev()
+		if c.expired(el.Value.(*entry), now) {
+			c.remove(el)
+			c.expirations++
+		}
+		el = prev
+	}
+}
+
+// overflows, expired и remove вызываются под c.mu.
+func (c *LRUCache) overflows(extra int64) bool {
+	return c.evict.Len()+1 > c.capacity || c.maxBytes > 0 && c.bytes+extra > c.maxBytes
+}
+
+func (c *LRUCache) expired(en *entry, now time.Time) bool {
+	return !en.expiresAt.IsZero() && now.After(en.expiresAt)
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	en := c.evict.Remove(el).(*entry)
+	delete(c.items, en.key)
+	c.bytes -= en.size()
+}
+
+func (c *LRUCache) Capacity() int {
+	return c.capacity
+}
+
+func (c *LRUCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+		Rejections:  c.rejections,
+		Len:         c.evict.Len(),
+		Bytes:       c.bytes,
+	}
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"hash/maphash"
+	"time"
+)
+
+// ShardedCache распределяет ключи по нескольким LRUCache по хешу, так что
+// конкурентные запросы к разным ключам почти не делят блокировки.
+// Ёмкость и лимит по байтам делятся между сегментами поровну.
+type ShardedCache struct {
+	seed     maphash.Seed
+	shards   []*LRUCache
+	sketches []*sketch
+	capacity int
+}
+
+func NewShardedCache(opts Options) *ShardedCache {
+	// число сегментов - степень двойки (см. shard), но не больше ёмкости и
+	// лимита по байтам: ноль в сегменте означал бы "без лимита"
+	n := 1
+	for n < opts.Shards {
+		n <<= 1
+	}
+	for n > 1 && (n > opts.Capacity || opts.MaxBytes > 0 && int64(n) > opts.MaxBytes) {
+		n >>= 1
+	}
+
+	c := &ShardedCache{
+		seed:     maphash.MakeSeed(),
+		shards:   make([]*LRUCache, n),
+		capacity: opts.Capacity,
+	}
+	// остаток от деления раздаём первым сегментам, чтобы сумма совпадала с лимитом
+	for i := range c.shards {
+		capacity := opts.Capacity / n
+		if i < opts.Capacity%n {
+			capacity++
+		}
+		maxBytes := opts.MaxBytes / int64(n)
+		if int64(i) < opts.MaxBytes%int64(n) {
+			maxBytes++
+		}
+		c.shards[i] = NewLRUCache(capacity, maxBytes, opts.TTL)
+	}
+	if opts.Admission == AdmissionTinyLFU {
+		c.sketches = make([]*sketch, n)
+		for i := range c.sketches {
+			sk := newSketch(c.shards[i].Capacity())
+			c.sketches[i] = sk
+			c.shards[i].admit = func(candidate, victim string) bool {
+				return sk.estimate(candidate) > sk.estimate(victim)
+			}
+		}
+	}
+	return c
+}
+
+func (c *ShardedCache) shard(key string) int {
+	return int(maphash.String(c.seed, key) & uint64(len(c.shards)-1))
+}
+
+func (c *ShardedCache) Get(key string) ([]byte, bool) {
+	i := c.shard(key)
+	if c.sketches != nil {
+		c.sketches[i].increment(key)
+	}
+	return c.shards[i].Get(key)
+}
+
+func (c *ShardedCache) Put(key string, value []byte) {
+	c.shards[c.shard(key)].Put(key, value)
+}
+
+func (c *ShardedCache) PutIfNewer(key string, value []byte, newer func(old, value []byte) bool) bool {
+	return c.shards[c.shard(key)].PutIfNewer(key, value, newer)
+}
+
+func (c *ShardedCache) Delete(key string) bool {
+	return c.shards[c.shard(key)].Delete(key)
+}
+
+func (c *ShardedCache) Capacity() int {
+	return c.capacity
+}
+
+func (c *ShardedCache) Stats() Stats {
+	var total Stats
+	for _, s := range c.shards {
+		st := s.Stats()
+		total.Hits += st.Hits
+		total.Misses += st.Misses
+		total.Evictions += st.Evictions
+		total.Expirations += st.Expirations
+		total.Rejections += st.Rejections
+		total.Len += st.Len
+		total.Bytes += st.Bytes
+	}
+	return total
+}
+
+func (c *ShardedCache) RunJanitor(ctx context.Context, interval time.Duration) {
+	if c.shards[0].ttl <= 0 || interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			for _, s := range c.shards {
+				s.removeExpired(now)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+const (
+	sketchDepth   = 4
+	sketchMaxFreq = 15
+)
+
+// sketch - count-min sketch для оценки частоты обращений к ключу, как в TinyLFU.
+// Счётчики насыщаются на 15, а после sampleSize обращений все делятся пополам,
+// чтобы старая популярность не жила вечно.
+type sketch struct {
+	mu         sync.Mutex
+	seed       maphash.Seed
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newSketch(capacity int) *sketch {
+	width := 16
+	for width < capacity*4 {
+		width <<= 1
+	}
+	s := &sketch{
+		seed:       maphash.MakeSeed(),
+		mask:       uint64(width - 1),
+		sampleSize: max(10*capacity, 64),
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// index возвращает позицию ключа в строке i (double hashing).
+func (s *sketch) index(h uint64, i int) uint64 {
+	h1, h2 := h, h>>32|h<<32
+	return (h1 + uint64(i)*h2) & s.mask
+}
+
+func (s *sketch) increment(key string) {
+	h := maphash.String(s.seed, key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(h, i)]; *c < sketchMaxFreq {
+			*c++
+		}
+	}
+	if s.additions++; s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *sketch) estimate(key string) uint8 {
+	h := maphash.String(s.seed, key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	freq := uint8(sketchMaxFreq)
+	for i := range s.rows {
+		freq = min(freq, s.rows[i][s.index(h, i)])
+	}
+	return freq
+}
+
+// reset вызывается под s.mu.
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshotter - кэш, содержимое которого можно сохранить в файл при остановке
+// и восстановить при старте.
+type Snapshotter interface {
+	// WriteSnapshot пишет записи вместе с порядком вытеснения.
+	WriteSnapshot(w io.Writer) error
+	// ReadSnapshot добавляет записи из снимка, пропуская сохранённые раньше
+	// чем maxAge назад (0 - без ограничения), и возвращает их число.
+	// Повреждённый снимок отвергается целиком.
+	ReadSnapshot(r io.Reader, maxAge time.Duration) (int, error)
+}
+
+// Формат снимка (все числа big-endian):
+//
+//	magic "OSCS" | version uint16 | count uint32
+//	count x (stored_at int64 unix nano | key_len uint32 | key | value_len uint32 | value)
+//	crc32c uint32 от всех предыдущих байт
+//
+// Записи идут от самой давней к самой свежей.
+const (
+	snapshotMagic   = "OSCS"
+	snapshotVersion = 1
+)
+
+var (
+	// ErrSnapshotCorrupt - снимок обрезан или не сходится контрольная сумма.
+	ErrSnapshotCorrupt = errors.New("cache snapshot is corrupt")
+	// ErrSnapshotVersion - снимок записан несовместимой версией формата.
+	ErrSnapshotVersion = errors.New("unsupported cache snapshot version")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+func writeSnapshot(w io.Writer, entries []entry) error {
+	crc := crc32.New(crcTable)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	var buf []byte
+	buf = append(buf, snapshotMagic...)
+	buf = binary.BigEndian.AppendUint16(buf, snapshotVersion)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(entries)))
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	for _, en := range entries {
+		buf = binary.BigEndian.AppendUint64(buf[:0], uint64(en.storedAt.UnixNano()))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(en.key)))
+		buf = append(buf, en.key...)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(en.value)))
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		if _, err := bw.Write(en.value); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+func readSnapshot(r io.Reader) ([]entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(snapshotMagic)+2+4+4 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, ErrSnapshotCorrupt
+	}
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(body, crcTable) != sum {
+		return nil, ErrSnapshotCorrupt
+	}
+
+	br := bytes.NewReader(body[len(snapshotMagic):])
+	var (
+		version uint16
+		count   uint32
+	)
+	binary.Read(br, binary.BigEndian, &version)
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w %d", ErrSnapshotVersion, version)
+	}
+	binary.Read(br, binary.BigEndian, &count)
+
+	readBytes := func() ([]byte, error) {
+		var n uint32
+		if err := binary.Read(br, binary.BigEndian, &n); err != nil {
+			return nil, ErrSnapshotCorrupt
+		}
+		if int64(n) > int64(br.Len()) {
+			return nil, ErrSnapshotCorrupt
+		}
+		b := make([]byte, n)
+		br.Read(b)
+		return b, nil
+	}
+
+	entries := make([]entry, 0, min(int(count), br.Len()))
+	for range count {
+		var storedAt int64
+		if err := binary.Read(br, binary.BigEndian, &storedAt); err != nil {
+			return nil, ErrSnapshotCorrupt
+		}
+		key, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		value, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key: string(key), value: value, storedAt: time.Unix(0, storedAt)})
+	}
+	if br.Len() != 0 {
+		return nil, ErrSnapshotCorrupt
+	}
+	return entries, nil
+}
+
+func (c *LRUCache) WriteSnapshot(w io.Writer) error {
+	return writeSnapshot(w, c.snapshotEntries())
+}
+
+func (c *LRUCache) ReadSnapshot(r io.Reader, maxAge time.Duration) (int, error) {
+	entries, err := readSnapshot(r)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.restore(entries, maxAge, time.Now()), nil
+}
+
+// snapshotEntries возвращает живые записи от самой давней к самой свежей.
+func (c *LRUCache) snapshotEntries() []entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]entry, 0, c.evict.Len())
+	for el := c.evict.Back(); el != nil; el = el.Prev() {
+		if en := el.Value.(*entry); !c.expired(en, now) {
+			entries = append(entries, *en)
+		}
+	}
+	return entries
+}
+
+// restore вставляет записи в порядке снимка; вызывается под c.mu.
+// Снимок читается, когда сервис уже работает, поэтому ключи, которые успели
+// попасть в кэш, свежее снимка и не перезаписываются.
+func (c *LRUCache) restore(entries []entry, maxAge time.Duration, now time.Time) int {
+	n := 0
+	for _, en := range entries {
+		if _, ok := c.items[en.key]; ok {
+			continue
+		}
+		if maxAge > 0 && now.Sub(en.storedAt) > maxAge {
+			continue
+		}
+		if c.ttl > 0 && now.Sub(en.storedAt) > c.ttl {
+			continue
+		}
+		c.put(&en)
+		n++
+	}
+	return n
+}
+
+// Сегменты пишутся подряд; при чтении записи заново распределяются по
+// сегментам, порядок вытеснения внутри каждого сохраняется.
+func (c *ShardedCache) WriteSnapshot(w io.Writer) error {
+	var entries []entry
+	for _, s := range c.shards {
+		entries = append(entries, s.snapshotEntries()...)
+	}
+	return writeSnapshot(w, entries)
+}
+
+func (c *ShardedCache) ReadSnapshot(r io.Reader, maxAge time.Duration) (int, error) {
+	entries, err := readSnapshot(r)
+	if err != nil {
+		return 0, err
+	}
+	byShard := make([][]entry, len(c.shards))
+	for _, en := range entries {
+		i := c.shard(en.key)
+		byShard[i] = append(byShard[i], en)
+	}
+	now := time.Now()
+	n := 0
+	for i, s := range c.shards {
+		s.mu.Lock()
+		n += s.restore(byShard[i], maxAge, now)
+		s.mu.Unlock()
+	}
+	return n, nil
+}
+
+// SaveSnapshot атомарно записывает снимок кэша в path.
+func SaveSnapshot(c Snapshotter, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := c.WriteSnapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot загружает снимок из path, см. Snapshotter.ReadSnapshot.
+func LoadSnapshot(c Snapshotter, path string, maxAge time.Duration) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return c.ReadSnapshot(f, maxAge)
+}
@@ -0,0 +1,354 @@
+// Package config загружает настройки сервиса из YAML-файла, переменных
+// окружения и флагов командной строки (в порядке возрастания приоритета).
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix - префикс переменных окружения: postgres.host -> ORDER_SERVICE_POSTGRES_HOST.
+const EnvPrefix = "ORDER_SERVICE_"
+
+const redacted = "******"
+
+type Config struct {
+	Log      Log      `yaml:"log"`
+	HTTP     HTTP     `yaml:"http"`
+	Postgres Postgres `yaml:"postgres"`
+	Kafka    Kafka    `yaml:"kafka"`
+	Cache    Cache    `yaml:"cache"`
+	Retry    Retry    `yaml:"retry"`
+	Admin    Admin    `yaml:"admin"`
+
+	// PrintConfig выставляется флагом --print-config и в файле не хранится.
+	PrintConfig bool `yaml:"-"`
+}
+
+type Log struct {
+	// Level - debug, info, warn или error.
+	Level string `yaml:"level"`
+	// Format - json или text.
+	Format string `yaml:"format"`
+}
+
+type HTTP struct {
+	Addr      string `yaml:"addr"`
+	StaticDir string `yaml:"static_dir"`
+}
+
+type Postgres struct {
+	// DSN, если задан, используется как есть, остальные поля подключения игнорируются.
+	DSN            string `yaml:"dsn"`
+	Host           string `yaml:"host"`
+	Port           int    `yaml:"port"`
+	User           string `yaml:"user"`
+	Password       string `yaml:"password"`
+	PasswordFile   string `yaml:"password_file"`
+	Database       string `yaml:"database"`
+	SSLMode        string `yaml:"sslmode"`
+	MigrationsPath string `yaml:"migrations_path"`
+}
+
+type Kafka struct {
+	Brokers  []string `yaml:"brokers"`
+	Topic    string   `yaml:"topic"`
+	GroupID  string   `yaml:"group_id"`
+	DLQTopic string   `yaml:"dlq_topic"`
+	// StatusTopic - события смены статуса заказа, пустая строка - не читать.
+	StatusTopic string `yaml:"status_topic"`
+	// ControlTopic - compacted-топик для сброса кэша на других экземплярах,
+	// пустая строка - не рассылать.
+	ControlTopic string `yaml:"control_topic"`
+}
+
+type Cache struct {
+	Capacity int `yaml:"capacity"`
+	// MaxBytes ограничивает суммарный размер закэшированных заказов, 0 - без ограничения.
+	MaxBytes int64 `yaml:"max_bytes"`
+	// TTL - время жизни записи, 0 - записи не устаревают.
+	TTL             Duration `yaml:"ttl"`
+	CleanupInterval Duration `yaml:"cleanup_interval"`
+	// NegativeTTL - сколько помнить, что заказа нет в БД, 0 - не помнить.
+	NegativeTTL Duration `yaml:"negative_ttl"`
+	// Shards > 1 включает сегментированный кэш.
+	Shards int `yaml:"shards"`
+	// Admission - none или tinylfu.
+	Admission string `yaml:"admission"`
+	// Warmup - чем заполнять кэш при старте: recent, popular или none.
+	Warmup         string `yaml:"warmup"`
+	WarmupPageSize int    `yaml:"warmup_page_size"`
+	// AccessLog - файл со счётчиками обращений к заказам для прогрева popular,
+	// пустая строка - счётчики не ведутся.
+	AccessLog              string   `yaml:"access_log"`
+	AccessLogFlushInterval Duration `yaml:"access_log_flush_interval"`
+	// Snapshot - файл, куда кэш сохраняется при остановке и откуда читается
+	// при старте вместо прогрева из БД; пустая строка - не сохранять.
+	Snapshot string `yaml:"snapshot"`
+	// SnapshotMaxAge - записи старше не восстанавливаются, 0 - без ограничения.
+	SnapshotMaxAge Duration `yaml:"snapshot_max_age"`
+}
+
+type Admin struct {
+	// Token - bearer-токен для /admin/*, пустой - админские эндпоинты выключены.
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+}
+
+type Retry struct {
+	MaxAttempts int      `yaml:"max_attempts"`
+	BaseDelay   Duration `yaml:"base_delay"`
+	MaxDelay    Duration `yaml:"max_delay"`
+}
+
+// Default возвращает конфигурацию для локального запуска через compose.yml.
+func Default() Config {
+	return Config{
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		HTTP: HTTP{
+			Addr:      ":8080",
+			StaticDir: "./static",
+		},
+		Postgres: Postgres{
+			Host:           "localhost",
+			Port:           5432,
+			User:           "someuser",
+			Password:       "some_password",
+			Database:       "orders",
+			SSLMode:        "disable",
+			MigrationsPath: "./migrations",
+		},
+		Kafka: Kafka{
+			Brokers:      []string{"localhost:9092"},
+			Topic:        "orders",
+			GroupID:      "order-service",
+			DLQTopic:     "orders.dlq",
+			StatusTopic:  "orders.status",
+			ControlTopic: "orders.cache-control",
+		},
+		Cache: Cache{
+			Capacity:               1000,
+			MaxBytes:               64 << 20,
+			TTL:                    Duration(10 * time.Minute),
+			CleanupInterval:        Duration(time.Minute),
+			NegativeTTL:            Duration(30 * time.Second),
+			Shards:                 16,
+			Admission:              "none",
+			Warmup:                 "recent",
+			WarmupPageSize:         100,
+			AccessLogFlushInterval: Duration(time.Minute),
+			SnapshotMaxAge:         Duration(time.Hour),
+		},
+		Retry: Retry{
+			MaxAttempts: 6,
+			BaseDelay:   Duration(200 * time.Millisecond),
+			MaxDelay:    Duration(10 * time.Second),
+		},
+	}
+}
+
+// Load собирает конфигурацию: значения по умолчанию, затем файл из -config
+// (или ORDER_SERVICE_CONFIG), затем переменные окружения, затем флаги.
+// Секреты из *_file подставляются последними, после чего конфигурация валидируется.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet("order-service", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(EnvPrefix+"CONFIG"), "path to YAML config file")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective config with secrets redacted and exit")
+	raw := make(map[string]string)
+	for _, f := range fields {
+		fs.Func(f.key, f.usage, func(v string) error {
+			raw[f.key] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", *configPath, err)
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := os.LookupEnv(f.env()); ok {
+			if err := f.set(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.env(), err)
+			}
+		}
+	}
+	for _, f := range fields {
+		if v, ok := raw[f.key]; ok {
+			if err := f.set(v); err != nil {
+				return nil, fmt.Errorf("-%s: %w", f.key, err)
+			}
+		}
+	}
+
+	if cfg.Postgres.PasswordFile != "" {
+		secret, err := os.ReadFile(cfg.Postgres.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read postgres password: %w", err)
+		}
+		cfg.Postgres.Password = strings.TrimSpace(string(secret))
+	}
+	if cfg.Admin.TokenFile != "" {
+		secret, err := os.ReadFile(cfg.Admin.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read admin token: %w", err)
+		}
+		cfg.Admin.Token = strings.TrimSpace(string(secret))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate проверяет, что с конфигурацией можно стартовать.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	var lvl slog.Level
+	check(lvl.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format must be json or text, got %q", c.Log.Format)
+	check(c.HTTP.Addr != "", "http.addr is required")
+	if c.Postgres.DSN == "" {
+		check(c.Postgres.Host != "", "postgres.host is required")
+		check(c.Postgres.Port > 0 && c.Postgres.Port < 65536, "postgres.port must be a valid port, got %d", c.Postgres.Port)
+		check(c.Postgres.User != "", "postgres.user is required")
+		check(c.Postgres.Database != "", "postgres.database is required")
+	} else {
+		_, ok := parseDSN(c.Postgres.DSN)
+		check(ok, "postgres.dsn must be a postgres:// URL")
+	}
+	check(c.Postgres.MigrationsPath != "", "postgres.migrations_path is required")
+	check(len(c.Kafka.Brokers) > 0, "kafka.brokers is required")
+	for _, b := range c.Kafka.Brokers {
+		_, _, err := net.SplitHostPort(b)
+		check(err == nil, "kafka.brokers: %q is not host:port", b)
+	}
+	check(c.Kafka.Topic != "", "kafka.topic is required")
+	check(c.Kafka.GroupID != "", "kafka.group_id is required")
+	check(c.Kafka.DLQTopic != "" && c.Kafka.DLQTopic != c.Kafka.Topic,
+		"kafka.dlq_topic must be set and differ from kafka.topic")
+	check(c.Kafka.StatusTopic != c.Kafka.Topic && c.Kafka.StatusTopic != c.Kafka.DLQTopic,
+		"kafka.status_topic must differ from kafka.topic and kafka.dlq_topic")
+	check(c.Kafka.ControlTopic == "" ||
+		c.Kafka.ControlTopic != c.Kafka.Topic && c.Kafka.ControlTopic != c.Kafka.DLQTopic && c.Kafka.ControlTopic != c.Kafka.StatusTopic,
+		"kafka.control_topic must differ from the other topics")
+	check(c.Cache.Capacity > 0, "cache.capacity must be positive, got %d", c.Cache.Capacity)
+	check(c.Cache.MaxBytes >= 0, "cache.max_bytes must not be negative")
+	check(c.Cache.TTL >= 0, "cache.ttl must not be negative")
+	check(c.Cache.NegativeTTL >= 0, "cache.negative_ttl must not be negative")
+	check(c.Cache.Shards >= 0, "cache.shards must not be negative")
+	check(c.Cache.Admission == "none" || c.Cache.Admission == "tinylfu",
+		"cache.admission must be none or tinylfu, got %q", c.Cache.Admission)
+	check(c.Cache.Warmup == "recent" || c.Cache.Warmup == "popular" || c.Cache.Warmup == "none",
+		"cache.warmup must be recent, popular or none, got %q", c.Cache.Warmup)
+	check(c.Cache.WarmupPageSize > 0, "cache.warmup_page_size must be positive, got %d", c.Cache.WarmupPageSize)
+	check(c.Cache.Warmup != "popular" || c.Cache.AccessLog != "",
+		"cache.access_log is required for cache.warmup=popular")
+	check(c.Cache.AccessLog == "" || c.Cache.AccessLogFlushInterval > 0,
+		"cache.access_log_flush_interval must be positive when cache.access_log is set")
+	check(c.Cache.SnapshotMaxAge >= 0, "cache.snapshot_max_age must not be negative")
+	check(c.Cache.TTL == 0 && c.Cache.NegativeTTL == 0 || c.Cache.CleanupInterval > 0,
+		"cache.cleanup_interval must be positive when cache.ttl or cache.negative_ttl is set")
+	check(c.Admin.Token == "" || len(c.Admin.Token) >= 16, "admin.token must be at least 16 characters")
+	check(c.Retry.MaxAttempts >= 1, "retry.max_attempts must be at least 1, got %d", c.Retry.MaxAttempts)
+	check(c.Retry.BaseDelay > 0 && c.Retry.MaxDelay >= c.Retry.BaseDelay,
+		"retry delays must be positive and base_delay <= max_delay")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// PostgresDSN возвращает строку подключения к Postgres.
+func (c *Config) PostgresDSN() string {
+	if c.Postgres.DSN != "" {
+		return c.Postgres.DSN
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Postgres.User, c.Postgres.Password),
+		Host:     net.JoinHostPort(c.Postgres.Host, strconv.Itoa(c.Postgres.Port)),
+		Path:     "/" + c.Postgres.Database,
+		RawQuery: url.Values{"sslmode": {c.Postgres.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// Redacted возвращает копию конфигурации без паролей.
+func (c *Config) Redacted() Config {
+	r := *c
+	r.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	if r.Postgres.Password != "" {
+		r.Postgres.Password = redacted
+	}
+	if r.Postgres.DSN != "" {
+		r.Postgres.DSN = redactDSN(r.Postgres.DSN)
+	}
+	if r.Admin.Token != "" {
+		r.Admin.Token = redacted
+	}
+	return r
+}
+
+// parseDSN разбирает DSN в виде URL. Строки вида "host=... password=..."
+// не поддерживаются: в них пароль не отделить надёжно от остальных параметров.
+func parseDSN(dsn string) (*url.URL, bool) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, false
+	}
+	return u, true
+}
+
+// redactDSN скрывает пароль в userinfo и в параметре password; DSN, который
+// не разбирается как URL, скрывается целиком.
+func redactDSN(dsn string) string {
+	u, ok := parseDSN(dsn)
+	if !ok {
+		return redacted
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", "xxxxx") // как url.URL.Redacted
+		u.RawQuery = q.Encode()
+	}
+	return u.Redacted()
+}
+
+// YAML сериализует конфигурацию со скрытыми паролями.
+func (c *Config) YAML() ([]byte, error) {
+	r := c.Redacted()
+	return yaml.Marshal(&r)
+}
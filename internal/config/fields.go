@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field связывает параметр конфигурации с флагом и переменной окружения.
+type field struct {
+	key   string
+	usage string
+	ptr   any
+}
+
+func (c *Config) fields() []field {
+	return []field{
+		{"log.level", "log level: debug, info, warn, error", &c.Log.Level},
+		{"log.format", "log format: json or text", &c.Log.Format},
+		{"http.addr", "HTTP listen address", &c.HTTP.Addr},
+		{"http.static_dir", "directory with static files", &c.HTTP.StaticDir},
+		{"postgres.dsn", "Postgres connection URL (overrides other postgres.* settings)", &c.Postgres.DSN},
+		{"postgres.host", "Postgres host", &c.Postgres.Host},
+		{"postgres.port", "Postgres port", &c.Postgres.Port},
+		{"postgres.user", "Postgres user", &c.Postgres.User},
+		{"postgres.password", "Postgres password", &c.Postgres.Password},
+		{"postgres.password_file", "file with the Postgres password", &c.Postgres.PasswordFile},
+		{"postgres.database", "Postgres database", &c.Postgres.Database},
+		{"postgres.sslmode", "Postgres sslmode", &c.Postgres.SSLMode},
+		{"postgres.migrations_path", "directory with SQL migrations", &c.Postgres.MigrationsPath},
+		{"kafka.brokers", "comma-separated Kafka brokers", &c.Kafka.Brokers},
+		{"kafka.topic", "orders topic", &c.Kafka.Topic},
+		{"kafka.group_id", "consumer group id", &c.Kafka.GroupID},
+		{"kafka.dlq_topic", "dead-letter topic for rejected orders", &c.Kafka.DLQTopic},
+		{"kafka.status_topic", "order status change topic (empty - disabled)", &c.Kafka.StatusTopic},
+		{"kafka.control_topic", "compacted topic for cross-instance cache invalidation (empty - disabled)", &c.Kafka.ControlTopic},
+		{"cache.capacity", "max number of cached orders", &c.Cache.Capacity},
+		{"cache.max_bytes", "max total size of cached orders in bytes (0 - unlimited)", &c.Cache.MaxBytes},
+		{"cache.ttl", "cached order lifetime (0 - no expiry)", &c.Cache.TTL},
+		{"cache.cleanup_interval", "how often expired cache entries are purged", &c.Cache.CleanupInterval},
+		{"cache.negative_ttl", "how long a missing order ID is remembered (0 - disabled)", &c.Cache.NegativeTTL},
+		{"cache.shards", "number of cache shards (0 or 1 - single LRU)", &c.Cache.Shards},
+		{"cache.admission", "cache admission policy: none or tinylfu", &c.Cache.Admission},
+		{"cache.warmup", "cache warmup strategy: recent, popular or none", &c.Cache.Warmup},
+		{"cache.warmup_page_size", "orders loaded per warmup page", &c.Cache.WarmupPageSize},
+		{"cache.access_log", "file with per-order access counts used by popular warmup", &c.Cache.AccessLog},
+		{"cache.access_log_flush_interval", "how often access counts are saved", &c.Cache.AccessLogFlushInterval},
+		{"cache.snapshot", "file the cache is saved to on shutdown and restored from on start", &c.Cache.Snapshot},
+		{"cache.snapshot_max_age", "skip snapshot entries older than this (0 - no limit)", &c.Cache.SnapshotMaxAge},
+		{"retry.max_attempts", "max attempts for transient DB errors", &c.Retry.MaxAttempts},
+		{"retry.base_delay", "initial retry backoff", &c.Retry.BaseDelay},
+		{"retry.max_delay", "max retry backoff", &c.Retry.MaxDelay},
+		{"admin.token", "bearer token for /admin endpoints (empty - disabled)", &c.Admin.Token},
+		{"admin.token_file", "file with the admin bearer token", &c.Admin.TokenFile},
+	}
+}
+
+func (f field) env() string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_").Replace(f.key))
+}
+
+func (f field) set(v string) error {
+	switch p := f.ptr.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *int64:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*p = Duration(d)
+	case *[]string:
+		var list []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		*p = list
+	default:
+		return fmt.Errorf("unsupported config field type %T", f.ptr)
+	}
+	return nil
+}
+
+// Duration - time.Duration, который в YAML записывается строкой вида "1m30s".
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalYAML() (any, error) { return d.String(), nil }
+
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
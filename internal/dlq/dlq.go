@@ -0,0 +1,72 @@
+// Package dlq описывает формат сообщений dead-letter топика заказов.
+package dlq
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Заголовки, которые сервис добавляет к сообщению при отправке в DLQ.
+const (
+	HeaderStage     = "x-dlq-stage"
+	HeaderError     = "x-dlq-error"
+	HeaderTopic     = "x-dlq-original-topic"
+	HeaderPartition = "x-dlq-original-partition"
+	HeaderOffset    = "x-dlq-original-offset"
+	HeaderAttempt   = "x-dlq-attempt"
+	HeaderFailedAt  = "x-dlq-failed-at"
+)
+
+// Стадии обработки, на которых сообщение может упасть.
+const (
+	StageParse    = "parse"
+	StageValidate = "validate"
+	StagePersist  = "persist"
+)
+
+// Message строит сообщение для DLQ-топика из исходного сообщения и причины отказа.
+func Message(topic string, msg *sarama.ConsumerMessage, stage string, cause error) *sarama.ProducerMessage {
+	// время исходного сообщения сохраняем: по нему сервис упорядочивает
+	// версии заказов без updated_at
+	out := &sarama.ProducerMessage{
+		Topic:     topic,
+		Value:     sarama.ByteEncoder(msg.Value),
+		Timestamp: msg.Timestamp,
+		Headers: []sarama.RecordHeader{
+			header(HeaderStage, stage),
+			header(HeaderError, cause.Error()),
+			header(HeaderTopic, msg.Topic),
+			header(HeaderPartition, strconv.Itoa(int(msg.Partition))),
+			header(HeaderOffset, strconv.FormatInt(msg.Offset, 10)),
+			header(HeaderAttempt, strconv.Itoa(Attempt(msg.Headers)+1)),
+			header(HeaderFailedAt, time.Now().UTC().Format(time.RFC3339)),
+		},
+	}
+	if msg.Key != nil {
+		out.Key = sarama.ByteEncoder(msg.Key)
+	}
+	return out
+}
+
+// Attempt возвращает номер попытки, записанный в заголовках, или 0, если сообщение
+// ещё ни разу не попадало в DLQ.
+func Attempt(headers []*sarama.RecordHeader) int {
+	n, _ := strconv.Atoi(Header(headers, HeaderAttempt))
+	return n
+}
+
+// Header возвращает значение заголовка key или пустую строку.
+func Header(headers []*sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
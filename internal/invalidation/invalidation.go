@@ -0,0 +1,62 @@
+// Package invalidation рассылает между экземплярами сервиса события об
+// изменении заказов, чтобы каждый из них сбросил или обновил свою копию в кэше.
+package invalidation
+
+import (
+	"context"
+	"sync"
+)
+
+// Event сообщает, что заказ записан в БД.
+type Event struct {
+	OrderUID string `json:"order_uid"`
+	// Version - номер записанной версии заказа (model.Order.Version);
+	// по нему получатель может отбросить запоздавшее событие.
+	Version int64 `json:"version"`
+	// Origin - экземпляр, записавший заказ; свои события он пропускает.
+	Origin string `json:"origin"`
+}
+
+// Transport доставляет события всем подписанным экземплярам.
+type Transport interface {
+	Publish(ctx context.Context, ev Event) error
+	// Subscribe вызывает fn для каждого события, пока не отменён ctx.
+	Subscribe(ctx context.Context, fn func(Event)) error
+}
+
+// Local - транспорт внутри одного процесса: события синхронно
+// доставляются всем текущим подписчикам. Подходит для тестов и для
+// запуска нескольких App в одном процессе.
+type Local struct {
+	mu   sync.RWMutex
+	next int
+	subs map[int]func(Event)
+}
+
+func NewLocal() *Local {
+	return &Local{subs: make(map[int]func(Event))}
+}
+
+func (l *Local) Publish(ctx context.Context, ev Event) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, fn := range l.subs {
+		fn(ev)
+	}
+	return nil
+}
+
+func (l *Local) Subscribe(ctx context.Context, fn func(Event)) error {
+	l.mu.Lock()
+	id := l.next
+	l.next++
+	l.subs[id] = fn
+	l.mu.Unlock()
+
+	<-ctx.Done()
+
+	l.mu.Lock()
+	delete(l.subs, id)
+	l.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,170 @@
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrNotConnected возвращает Publish, пока Subscribe не подключился к Kafka.
+var ErrNotConnected = errors.New("invalidation transport is not connected")
+
+const connectRetryDelay = 5 * time.Second
+
+// Kafka - транспорт через compacted-топик: ключ сообщения - order_uid,
+// поэтому в топике хранится только последнее событие по каждому заказу.
+// Каждый экземпляр читает все партиции без consumer group, начиная с новых
+// сообщений: пропущенное за время простоя покрывается TTL кэша.
+type Kafka struct {
+	brokers []string
+	topic   string
+
+	mu       sync.RWMutex
+	producer sarama.SyncProducer
+}
+
+// NewKafka создаёт транспорт; подключение выполняет Subscribe.
+func NewKafka(brokers []string, topic string) *Kafka {
+	return &Kafka{brokers: brokers, topic: topic}
+}
+
+func (k *Kafka) config() *sarama.Config {
+	config := sarama.NewConfig()
+	// версия нужна для создания топика с настройками брокера по умолчанию
+	config.Version = sarama.V2_4_0_0
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	return config
+}
+
+func (k *Kafka) Publish(ctx context.Context, ev Event) error {
+	k.mu.RLock()
+	producer := k.producer
+	k.mu.RUnlock()
+	if producer == nil {
+		return ErrNotConnected
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, _, err = producer.SendMessage(&sarama.ProducerMessage{
+		Topic: k.topic,
+		Key:   sarama.StringEncoder(ev.OrderUID),
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
+// Subscribe подключается к Kafka (повторяя попытки, пока не отменён ctx),
+// создаёт топик при необходимости и читает события до отмены ctx.
+func (k *Kafka) Subscribe(ctx context.Context, fn func(Event)) error {
+	client, err := k.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	k.producer = producer
+	k.mu.Unlock()
+	defer func() {
+		k.mu.Lock()
+		k.producer = nil
+		k.mu.Unlock()
+		producer.Close()
+	}()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	partitions, err := consumer.Partitions(k.topic)
+	if err != nil {
+		return fmt.Errorf("list partitions of %s: %w", k.topic, err)
+	}
+	msgs := make(chan *sarama.ConsumerMessage)
+	for _, p := range partitions {
+		pc, err := consumer.ConsumePartition(k.topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return fmt.Errorf("consume %s/%d: %w", k.topic, p, err)
+		}
+		defer pc.Close()
+		go func() {
+			for msg := range pc.Messages() {
+				select {
+				case msgs <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	slog.Info("Subscribed to cache invalidation topic", "topic", k.topic, "partitions", len(partitions))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-msgs:
+			var ev Event
+			if err := json.Unmarshal(msg.Value, &ev); err != nil {
+				slog.Warn("Malformed invalidation event", "partition", msg.Partition, "offset", msg.Offset, "error", err)
+				continue
+			}
+			fn(ev)
+		}
+	}
+}
+
+func (k *Kafka) connect(ctx context.Context) (sarama.Client, error) {
+	for {
+		client, err := sarama.NewClient(k.brokers, k.config())
+		if err == nil {
+			if err = k.ensureTopic(client); err == nil {
+				return client, nil
+			}
+			client.Close()
+		}
+		slog.Warn("Invalidation topic not available, retrying", "topic", k.topic, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(connectRetryDelay):
+		}
+	}
+}
+
+// ensureTopic создаёт compacted-топик, если его ещё нет.
+func (k *Kafka) ensureTopic(client sarama.Client) error {
+	admin, err := sarama.NewClusterAdmin(k.brokers, k.config())
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	compact := "compact"
+	err = admin.CreateTopic(k.topic, &sarama.TopicDetail{
+		NumPartitions:     -1,
+		ReplicationFactor: -1,
+		ConfigEntries:     map[string]*string{"cleanup.policy": &compact},
+	}, false)
+	if err != nil && !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return fmt.Errorf("create topic %s: %w", k.topic, err)
+	}
+	return client.RefreshMetadata(k.topic)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Encode сериализует заказ в каноническое представление API. Кэш и HTTP
+// отдают только его, поэтому заказ из Kafka и тот же заказ, прочитанный
+// из БД, дают одинаковые байты.
+func Encode(o Order) ([]byte, error) {
+	return json.Marshal(o.Canonical())
+}
+
+// Canonical возвращает копию заказа, приведённую к виду, в котором его
+// возвращает БД:
+//   - UUID в нижнем регистре без скобок и префиксов;
+//   - date_created и updated_at в UTC с точностью до микросекунд (как timestamptz);
+//   - items - не nil и упорядочены по chrt_id, rid.
+func (o Order) Canonical() Order {
+	o.OrderUID = canonicalUUID(o.OrderUID)
+	o.Payment.Transaction = canonicalUUID(o.Payment.Transaction)
+	o.Payment.RequestID = canonicalUUID(o.Payment.RequestID)
+	o.DateCreated = o.DateCreated.UTC().Truncate(time.Microsecond)
+	o.UpdatedAt = o.UpdatedAt.UTC().Truncate(time.Microsecond)
+
+	items := make([]Item, len(o.Items))
+	for i, it := range o.Items {
+		it.Rid = canonicalUUID(it.Rid)
+		items[i] = it
+	}
+	slices.SortStableFunc(items, func(a, b Item) int {
+		return cmp.Or(cmp.Compare(a.ChrtID, b.ChrtID), strings.Compare(a.Rid, b.Rid))
+	})
+	o.Items = items
+	return o
+}
+
+func canonicalUUID(s string) string {
+	if id, err := uuid.Parse(s); err == nil {
+		return id.String()
+	}
+	return s
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current Encode output")
+
+// Заказ из Kafka после записи в БД: статус и версию проставляет репозиторий.
+func fromKafka(t *testing.T, name string) Order {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".kafka.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Status = StatusCreated
+	o.Version = 1
+	return o
+}
+
+// Тот же заказ в том виде, в котором его собирает queryOrders: UUID из
+// колонок uuid в нижнем регистре, timestamptz с микросекундами в зоне
+// сессии, позиции по chrt_id, rid, пустой request_id из COALESCE.
+func fromDB(order, tx string, created, updated time.Time, items []Item) Order {
+	msk := time.FixedZone("MSK", 3*60*60)
+	return Order{
+		OrderUID:    order,
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: Payment{
+			Transaction:  tx,
+			RequestID:    "",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1500 + totalOf(items),
+			PaymentDT:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   totalOf(items),
+		},
+		Items:           items,
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		ShardKey:        9,
+		SmID:            99,
+		DateCreated:     created.Truncate(time.Microsecond).In(msk),
+		OofShard:        1,
+		UpdatedAt:       updated.Truncate(time.Microsecond).In(msk),
+		Status:          StatusCreated,
+		Version:         1,
+	}
+}
+
+func totalOf(items []Item) int {
+	n := 0
+	for _, it := range items {
+		n += it.TotalPrice
+	}
+	return n
+}
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestEncodeGolden(t *testing.T) {
+	brush := func(rid string) Item {
+		return Item{ChrtID: 1234567, TrackNumber: "WBILMTESTTRACK", Price: 100, Rid: rid, Name: "Brush",
+			Size: "0", TotalPrice: 100, NmID: 1111, Brand: "Vivienne Sabo", Status: 202}
+	}
+	mascaras := Item{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453,
+		Rid: "ab421908-7a76-4ae0-b7e5-5d1c9a3f2b10", Name: "Mascaras", Sale: 30, Size: "0",
+		TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202}
+
+	tests := []struct {
+		golden string
+		kafka  Order
+		db     Order
+	}{
+		{
+			golden: "order",
+			kafka:  fromKafka(t, "order"),
+			db: fromDB("b563feb7-b2b8-4b6c-9b5d-1f5c8a2e6d10", "7f2c1a9e-3d4b-4e5f-8a6b-0c1d2e3f4a5b",
+				mustTime(t, "2021-11-26T09:22:19.123456789+03:00"),
+				mustTime(t, "2021-11-26T09:30:00.000000999+03:00"),
+				[]Item{
+					brush("0a1b2c3d-4e5f-4a6b-8c7d-8e9fa0b1c2d3"),
+					brush("c0ffee00-1111-4222-8333-444455556666"),
+					mascaras,
+				}),
+		},
+		{
+			golden: "order_no_items",
+			kafka:  fromKafka(t, "order_no_items"),
+			db: fromDB("5e3c9d1a-77b0-4f0e-9c2a-6b8d4e1f0a92", "9a8b7c6d-5e4f-4a3b-9c2d-1e0f9a8b7c6d",
+				mustTime(t, "2021-11-26T06:22:19.5-00:30"),
+				mustTime(t, "2021-11-26T06:22:19.500000001-00:30"),
+				[]Item{}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.golden+".golden")
+			got, err := Encode(tt.kafka)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// кэш хранит результат Encode, поэтому повторное кодирование
+			// закэшированного заказа тоже должно давать те же байты
+			cached, err := Parse(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, o := range map[string]Order{"kafka": tt.kafka, "db": tt.db, "cache": cached} {
+				got, err := Encode(o)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s: Encode mismatch\ngot:  %s\nwant: %s", name, got, want)
+				}
+			}
+		})
+	}
+}
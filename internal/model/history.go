@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Source - откуда пришло изменение заказа.
+type Source struct {
+	// Topic пустой, если изменение сделано через HTTP API.
+	Topic     string `json:"topic,omitempty"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// Version - одна сохранённая версия заказа.
+type Version struct {
+	Number     int       `json:"version"`
+	ReceivedAt time.Time `json:"received_at"`
+	Source     *Source   `json:"source,omitempty"`
+	// Changes - отличия от предыдущей версии; у первой версии пусто.
+	Changes []Change `json:"changes"`
+	// Order - заказ в каноническом представлении (см. Encode). В списке
+	// версий не заполняется.
+	Order json.RawMessage `json:"order,omitempty"`
+}
+
+// Change - изменение одного поля. Old отсутствует у добавленных полей и
+// элементов, New - у удалённых.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Diff сравнивает два JSON-представления заказа и возвращает изменённые поля
+// в порядке обхода: ключи объектов по алфавиту, элементы массивов по индексу.
+// Пустой old означает, что предыдущей версии нет.
+func Diff(old, new []byte) ([]Change, error) {
+	if len(old) == 0 {
+		return nil, nil
+	}
+	var a, b any
+	if err := json.Unmarshal(old, &a); err != nil {
+		return nil, fmt.Errorf("diff: old version: %w", err)
+	}
+	if err := json.Unmarshal(new, &b); err != nil {
+		return nil, fmt.Errorf("diff: new version: %w", err)
+	}
+	var changes []Change
+	diffValues("", a, b, &changes)
+	return changes, nil
+}
+
+func diffValues(path string, a, b any, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			keys := make([]string, 0, len(av)+len(bv))
+			for k := range av {
+				keys = append(keys, k)
+			}
+			for k := range bv {
+				if _, ok := av[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				diffValues(join(path, k), av[k], bv[k], changes)
+			}
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			for i := range max(len(av), len(bv)) {
+				var x, y any
+				if i < len(av) {
+					x = av[i]
+				}
+				if i < len(bv) {
+					y = bv[i]
+				}
+				diffValues(fmt.Sprintf("%s[%d]", path, i), x, y, changes)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Old: a, New: b})
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,99 @@
+// Package model описывает заказ в том виде, в котором он приходит из Kafka
+// и отдаётся через HTTP API.
+package model
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// Order - заказ вместе с доставкой, оплатой и позициями.
+// Поля shardkey и oof_shard в JSON передаются строками ("9"), но хранятся числами.
+type Order struct {
+	OrderUID        string    `json:"order_uid"`
+	TrackNumber     string    `json:"track_number"`
+	Entry           string    `json:"entry"`
+	Delivery        Delivery  `json:"delivery"`
+	Payment         Payment   `json:"payment"`
+	Items           []Item    `json:"items"`
+	Locale          string    `json:"locale"`
+	InternalSig     string    `json:"internal_signature"`
+	CustomerID      string    `json:"customer_id"`
+	DeliveryService string    `json:"delivery_service"`
+	ShardKey        int16     `json:"shardkey,string"`
+	SmID            int       `json:"sm_id"`
+	DateCreated     time.Time `json:"date_created"`
+	OofShard        int16     `json:"oof_shard,string"`
+	// UpdatedAt - время изменения заказа у источника; по нему отбрасываются
+	// версии, пришедшие не по порядку.
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Status ведёт сервис: во входящих заказах поле игнорируется, новый
+	// заказ получает StatusCreated, дальше статус меняет только UpdateStatus.
+	Status Status `json:"status"`
+	// Version - номер последней сохранённой версии заказа, тоже ведётся
+	// сервисом. Его же API отдаёт в ETag и ждёт в If-Match.
+	Version int `json:"version"`
+}
+
+type Delivery struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Zip     string `json:"zip"`
+	City    string `json:"city"`
+	Address string `json:"address"`
+	Region  string `json:"region"`
+	Email   string `json:"email"`
+}
+
+type Payment struct {
+	Transaction  string `json:"transaction"`
+	RequestID    string `json:"request_id"`
+	Currency     string `json:"currency"`
+	Provider     string `json:"provider"`
+	Amount       int    `json:"amount"`
+	PaymentDT    int64  `json:"payment_dt"`
+	Bank         string `json:"bank"`
+	DeliveryCost int    `json:"delivery_cost"`
+	GoodsTotal   int    `json:"goods_total"`
+	CustomFee    int    `json:"custom_fee"`
+}
+
+type Item struct {
+	ChrtID      int64  `json:"chrt_id"`
+	TrackNumber string `json:"track_number"`
+	Price       int    `json:"price"`
+	Rid         string `json:"rid"`
+	Name        string `json:"name"`
+	Sale        int    `json:"sale"`
+	Size        string `json:"size"`
+	TotalPrice  int    `json:"total_price"`
+	NmID        int64  `json:"nm_id"`
+	Brand       string `json:"brand"`
+	Status      int    `json:"status"`
+}
+
+// ExpectedAmount возвращает сумму, которую должен составлять платёж.
+func (p Payment) ExpectedAmount() int {
+	return p.GoodsTotal + p.DeliveryCost + p.CustomFee
+}
+
+// Parse разбирает заказ из JSON-представления.
+func Parse(data []byte) (Order, error) {
+	var o Order
+	err := json.Unmarshal(data, &o)
+	return o, err
+}
+
+// LogValue отдаёт в логи только поля без персональных данных покупателя.
+func (o Order) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("order_uid", o.OrderUID),
+		slog.String("track_number", o.TrackNumber),
+		slog.String("entry", o.Entry),
+		slog.Int("items", len(o.Items)),
+		slog.Int("amount", o.Payment.Amount),
+		slog.String("currency", o.Payment.Currency),
+	)
+}
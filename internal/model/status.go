@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status - этап жизненного цикла заказа.
+type Status string
+
+const (
+	StatusCreated    Status = "created"
+	StatusPaid       Status = "paid"
+	StatusAssembling Status = "assembling"
+	StatusShipped    Status = "shipped"
+	StatusDelivered  Status = "delivered"
+	StatusCancelled  Status = "cancelled"
+	StatusReturned   Status = "returned"
+)
+
+// transitions - допустимые переходы; cancelled и returned конечные.
+var transitions = map[Status][]Status{
+	StatusCreated:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusAssembling, StatusCancelled},
+	StatusAssembling: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusReturned},
+	StatusDelivered:  {StatusReturned},
+	StatusCancelled:  nil,
+	StatusReturned:   nil,
+}
+
+// ErrIllegalTransition - переход между статусами запрещён.
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// Valid сообщает, известен ли статус.
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// ParseStatus проверяет, что строка - известный статус.
+func ParseStatus(s string) (Status, error) {
+	if st := Status(s); st.Valid() {
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown order status %q", s)
+}
+
+// Transition проверяет переход from -> to. Переход в тот же статус разрешён
+// и ничего не меняет: события о статусе могут прийти повторно.
+func Transition(from, to Status) error {
+	if !to.Valid() {
+		return fmt.Errorf("unknown order status %q", to)
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, from, to)
+}
+
+// StatusChange - событие смены статуса из Kafka.
+type StatusChange struct {
+	OrderUID string `json:"order_uid"`
+	Status   Status `json:"status"`
+}
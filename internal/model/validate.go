@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+
+	"order-service/internal/validation"
+)
+
+// Validate проверяет заказ целиком и возвращает все найденные нарушения.
+func (o *Order) Validate() validation.Errors {
+	var errs validation.Errors
+
+	errs.UUID("order_uid", o.OrderUID)
+	errs.Required("track_number", o.TrackNumber)
+	errs.Required("entry", o.Entry)
+	errs.Required("customer_id", o.CustomerID)
+	if o.DateCreated.IsZero() {
+		errs.Add("date_created", validation.CodeRequired, "must be set")
+	}
+
+	d := o.Delivery
+	errs.Required("delivery.name", d.Name)
+	if errs.Required("delivery.phone", d.Phone) && !validation.IsPhone(d.Phone) {
+		errs.Add("delivery.phone", validation.CodeFormat, "must be a phone number")
+	}
+	if d.Email != "" && !validation.IsEmail(d.Email) {
+		errs.Add("delivery.email", validation.CodeFormat, "must be an e-mail address")
+	}
+
+	p := o.Payment
+	errs.UUID("payment.transaction", p.Transaction)
+	if p.RequestID != "" {
+		errs.UUID("payment.request_id", p.RequestID)
+	}
+	if !validation.IsCurrency(p.Currency) {
+		errs.Add("payment.currency", validation.CodeFormat, "must be an ISO 4217 currency code, got %q", p.Currency)
+	}
+	okAmounts := errs.NonNegative("payment.amount", int64(p.Amount))
+	okAmounts = errs.NonNegative("payment.goods_total", int64(p.GoodsTotal)) && okAmounts
+	okAmounts = errs.NonNegative("payment.delivery_cost", int64(p.DeliveryCost)) && okAmounts
+	okAmounts = errs.NonNegative("payment.custom_fee", int64(p.CustomFee)) && okAmounts
+	if okAmounts {
+		if sum := p.ExpectedAmount(); p.Amount != sum {
+			errs.Add("payment.amount", validation.CodeMismatch,
+				"must equal goods_total + delivery_cost + custom_fee = %d, got %d", sum, p.Amount)
+		}
+	}
+	if p.PaymentDT <= 0 {
+		errs.Add("payment.payment_dt", validation.CodeRequired, "must be a unix timestamp")
+	}
+
+	if len(o.Items) == 0 {
+		errs.Add("items", validation.CodeRequired, "must contain at least one item")
+	}
+	for i, it := range o.Items {
+		field := func(name string) string { return fmt.Sprintf("items[%d].%s", i, name) }
+		errs.UUID(field("rid"), it.Rid)
+		errs.Required(field("name"), it.Name)
+		if it.TrackNumber != o.TrackNumber {
+			errs.Add(field("track_number"), validation.CodeMismatch,
+				"must match order track_number %q, got %q", o.TrackNumber, it.TrackNumber)
+		}
+		errs.NonNegative(field("price"), int64(it.Price))
+		errs.NonNegative(field("total_price"), int64(it.TotalPrice))
+		if it.Sale < 0 || it.Sale > 100 {
+			errs.Add(field("sale"), validation.CodeRange, "must be between 0 and 100, got %d", it.Sale)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"order-service/internal/apperr"
+	"order-service/internal/model"
+)
+
+// testOrderRepository проверяет семантику OrderRepository, общую для всех
+// реализаций; Memory должна вести себя так же, как Postgres.
+func testOrderRepository(t *testing.T, newRepo func() OrderRepository) {
+	t.Run("CanonicalIDs", func(t *testing.T) { testCanonicalIDs(t, newRepo()) })
+	t.Run("StaleUpsert", func(t *testing.T) { testStaleUpsert(t, newRepo()) })
+	t.Run("VersionOnChange", func(t *testing.T) { testVersionOnChange(t, newRepo()) })
+	t.Run("UpdateStatusIfMatch", func(t *testing.T) { testUpdateStatusIfMatch(t, newRepo()) })
+	t.Run("ListPaging", func(t *testing.T) { testListPaging(t, newRepo()) })
+}
+
+func TestMemory(t *testing.T) {
+	testOrderRepository(t, func() OrderRepository { return NewMemory() })
+}
+
+var testBase = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func testOrder(i int) model.Order {
+	uid := fmt.Sprintf("00000000-0000-4000-8000-%012d", i)
+	return model.Order{
+		OrderUID:    uid,
+		TrackNumber: fmt.Sprintf("TRACK%d", i),
+		Payment:     model.Payment{Transaction: uid, Amount: 100, GoodsTotal: 100},
+		Items: []model.Item{
+			{ChrtID: 2, Rid: fmt.Sprintf("10000000-0000-4000-8000-%012d", i), Price: 60, TotalPrice: 60},
+			{ChrtID: 1, Rid: fmt.Sprintf("20000000-0000-4000-8000-%012d", i), Price: 40, TotalPrice: 40},
+		},
+		DateCreated: testBase.Add(time.Duration(i) * time.Minute),
+		UpdatedAt:   testBase.Add(time.Duration(i) * time.Minute),
+	}
+}
+
+func upsert(t *testing.T, repo OrderRepository, o model.Order) model.Order {
+	t.Helper()
+	if err := repo.Upsert(context.Background(), &o, nil); err != nil {
+		t.Fatalf("Upsert(%s): %v", o.OrderUID, err)
+	}
+	return o
+}
+
+func get(t *testing.T, repo OrderRepository, uid string) model.Order {
+	t.Helper()
+	o, err := repo.Get(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", uid, err)
+	}
+	return o
+}
+
+func testCanonicalIDs(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+
+	bad := testOrder(1)
+	bad.Items[0].Rid = "not-a-uuid"
+	if err := repo.Upsert(ctx, &bad, nil); err == nil {
+		t.Error("Upsert accepted a non-UUID item rid")
+	}
+
+	o := testOrder(1)
+	o.OrderUID = strings.ToUpper(o.OrderUID)
+	o.Payment.Transaction = strings.ToUpper(o.Payment.Transaction)
+	upsert(t, repo, o)
+
+	got := get(t, repo, strings.ToLower(o.OrderUID))
+	if got.OrderUID != strings.ToLower(o.OrderUID) || got.Payment.Transaction != strings.ToLower(o.Payment.Transaction) {
+		t.Errorf("stored IDs are not canonical: %s, %s", got.OrderUID, got.Payment.Transaction)
+	}
+	if got.Items[0].ChrtID != 1 || got.Items[1].ChrtID != 2 {
+		t.Errorf("items are not sorted by chrt_id: %+v", got.Items)
+	}
+	if _, err := repo.Get(ctx, o.OrderUID); err != nil {
+		t.Errorf("Get with an uppercase UUID: %v", err)
+	}
+}
+
+func testStaleUpsert(t *testing.T, repo OrderRepository) {
+	newer := testOrder(1)
+	newer.UpdatedAt = testBase.Add(time.Hour)
+	newer.TrackNumber = "NEW"
+	upsert(t, repo, newer)
+
+	older := testOrder(1)
+	older.TrackNumber = "OLD"
+	if err := repo.Upsert(context.Background(), &older, nil); !errors.Is(err, ErrStale) {
+		t.Fatalf("Upsert of an older snapshot: got %v, want ErrStale", err)
+	}
+	if got := get(t, repo, newer.OrderUID); got.TrackNumber != "NEW" {
+		t.Errorf("older snapshot overwrote the newer one: track %q", got.TrackNumber)
+	}
+}
+
+func testVersionOnChange(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	o := testOrder(1)
+	if v := upsert(t, repo, o).Version; v != 1 {
+		t.Fatalf("first version = %d, want 1", v)
+	}
+	if v := upsert(t, repo, o).Version; v != 1 {
+		t.Errorf("redelivery changed the version to %d", v)
+	}
+	o.TrackNumber = "CHANGED"
+	if v := upsert(t, repo, o).Version; v != 2 {
+		t.Errorf("version after a change = %d, want 2", v)
+	}
+
+	history, err := repo.History(ctx, o.OrderUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history has %d versions, want 2", len(history))
+	}
+	if len(history[1].Changes) != 1 || history[1].Changes[0].Path != "track_number" {
+		t.Errorf("version 2 changes = %+v, want only track_number", history[1].Changes)
+	}
+}
+
+func testUpdateStatusIfMatch(t *testing.T, repo OrderRepository) {
+	ctx := context.Background()
+	uid := upsert(t, repo, testOrder(1)).OrderUID
+
+	_, err := repo.UpdateStatus(ctx, uid, model.StatusPaid, nil, 5)
+	if apperr.KindOf(err) != apperr.FailedPrecondition {
+		t.Errorf("UpdateStatus with a wrong If-Match: got %v, want FailedPrecondition", err)
+	}
+	if got := get(t, repo, uid); got.Status != model.StatusCreated || got.Version != 1 {
+		t.Errorf("failed precondition changed the order: %s v%d", got.Status, got.Version)
+	}
+
+	from, err := repo.UpdateStatus(ctx, uid, model.StatusPaid, nil, 1)
+	if err != nil || from != model.StatusCreated {
+		t.Fatalf("UpdateStatus with a matching If-Match: from %s, err %v", from, err)
+	}
+	if got := get(t, repo, uid); got.Status != model.StatusPaid || got.Version != 2 {
+		t.Errorf("after UpdateStatus: %s v%d, want paid v2", got.Status, got.Version)
+	}
+
+	if _, err := repo.UpdateStatus(ctx, uid, model.StatusPaid, nil, AnyVersion); err != nil {
+		t.Errorf("repeating the current status: %v", err)
+	}
+	if got := get(t, repo, uid); got.Version != 2 {
+		t.Errorf("repeating the current status bumped the version to %d", got.Version)
+	}
+	_, err = repo.UpdateStatus(ctx, uid, model.StatusDelivered, nil, AnyVersion)
+	if apperr.KindOf(err) != apperr.Conflict {
+		t.Errorf("illegal transition: got %v, want Conflict", err)
+	}
+	if _, err := repo.UpdateStatus(ctx, testOrder(2).OrderUID, model.StatusPaid, nil, AnyVersion); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateStatus of a missing order: got %v, want ErrNotFound", err)
+	}
+}
+
+func testListPaging(t *testing.T, repo OrderRepository) {
+	const total = 7
+	for i := range total {
+		upsert(t, repo, testOrder(i))
+	}
+
+	for _, desc := range []bool{false, true} {
+		var uids []string
+		q := ListQuery{Limit: 3, Desc: desc}
+		for {
+			page, err := repo.List(context.Background(), q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range page {
+				uids = append(uids, o.OrderUID)
+			}
+			if len(page) < q.Limit {
+				break
+			}
+			q.After = CursorOf(page[len(page)-1])
+		}
+
+		if len(uids) != total {
+			t.Fatalf("desc=%v: paged through %d orders, want %d", desc, len(uids), total)
+		}
+		for i, uid := range uids {
+			n := i
+			if desc {
+				n = total - 1 - i
+			}
+			if want := testOrder(n).OrderUID; uid != want {
+				t.Errorf("desc=%v: order %d is %s, want %s", desc, i, uid, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"order-service/internal/model"
+)
+
+// Memory хранит заказы в памяти процесса. Подходит для тестов и локального запуска.
+// Заказы хранятся в каноническом виде и проверяются так же, как в Postgres.
+type Memory struct {
+	mu       sync.RWMutex
+	orders   map[string]model.Order
+	versions map[string][]model.Version
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		orders:   make(map[string]model.Order),
+		versions: make(map[string][]model.Version),
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, orderUID string) (model.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.orders[canonicalUID(orderUID)]
+	if !ok {
+		return model.Order{}, ErrNotFound
+	}
+	return clone(o), nil
+}
+
+func (m *Memory) Upsert(ctx context.Context, order *model.Order, src *model.Source) error {
+	if _, _, _, err := parseUUIDs(*order); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if order.UpdatedAt.IsZero() {
+		order.UpdatedAt = time.Now()
+	}
+	// храним то, что вернул бы Postgres: UUID в нижнем регистре,
+	// время с точностью до микросекунд, позиции по chrt_id, rid
+	o := order.Canonical()
+	o.Status = model.StatusCreated
+	if old, ok := m.orders[o.OrderUID]; ok {
+		if o.UpdatedAt.Before(old.UpdatedAt) {
+			return ErrStale
+		}
+		o.Status = old.Status
+	}
+	if err := m.recordVersion(&o, src); err != nil {
+		return err
+	}
+	order.Status, order.Version = o.Status, o.Version
+	return nil
+}
+
+func (m *Memory) UpdateStatus(ctx context.Context, orderUID string, to model.Status, src *model.Source, ifVersion int) (model.Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[canonicalUID(orderUID)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	from := o.Status
+	if ifVersion != AnyVersion && ifVersion != o.Version {
+		return from, versionMismatch(ifVersion, o.Version)
+	}
+	if err := transition(from, to); err != nil {
+		return from, err
+	}
+	if from == to {
+		return from, nil
+	}
+	o.Status = to
+	return from, m.recordVersion(&o, src)
+}
+
+// recordVersion сохраняет заказ и, если он изменился, новую версию;
+// вызывается под m.mu.
+func (m *Memory) recordVersion(o *model.Order, src *model.Source) error {
+	versions := m.versions[o.OrderUID]
+	var prev []byte
+	if n := len(versions); n > 0 {
+		prev = versions[n-1].Order
+	}
+	o.Version = len(versions) + 1
+	data, changes, err := versionDiff(*o, prev)
+	if err != nil {
+		return err
+	}
+	if prev != nil && len(changes) == 0 {
+		o.Version = len(versions)
+		m.orders[o.OrderUID] = clone(*o)
+		return nil
+	}
+
+	v := model.Version{
+		Number:     o.Version,
+		ReceivedAt: time.Now(),
+		Changes:    changes,
+		Order:      data,
+	}
+	if src != nil {
+		s := *src
+		v.Source = &s
+	}
+	m.versions[o.OrderUID] = append(versions, v)
+	m.orders[o.OrderUID] = clone(*o)
+	return nil
+}
+
+func (m *Memory) History(ctx context.Context, orderUID string) ([]model.Version, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.versions[canonicalUID(orderUID)]
+	history := make([]model.Version, 0, len(versions))
+	for _, v := range versions {
+		v.Order = nil
+		history = append(history, v)
+	}
+	return history, nil
+}
+
+func (m *Memory) Version(ctx context.Context, orderUID string, n int) (model.Version, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.versions[canonicalUID(orderUID)]
+	if n < 1 || n > len(versions) {
+		return model.Version{}, ErrVersionNotFound
+	}
+	return versions[n-1], nil
+}
+
+func (m *Memory) List(ctx context.Context, q ListQuery) ([]model.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var res []model.Order
+	for _, o := range m.orders {
+		if !q.match(o) {
+			continue
+		}
+		if q.After != nil {
+			pos := *CursorOf(o)
+			if q.Desc && !pos.less(*q.After) || !q.Desc && !q.After.less(pos) {
+				continue
+			}
+		}
+		res = append(res, clone(o))
+	}
+	slices.SortFunc(res, func(a, b model.Order) int {
+		ca, cb := *CursorOf(a), *CursorOf(b)
+		c := 0
+		if ca.less(cb) {
+			c = -1
+		} else if cb.less(ca) {
+			c = 1
+		}
+		if q.Desc {
+			c = -c
+		}
+		return c
+	})
+	if len(res) > q.limit() {
+		res = res[:q.limit()]
+	}
+	return res, nil
+}
+
+func (m *Memory) NthNewest(ctx context.Context, n int) (*Cursor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if n < 1 || n > len(m.orders) {
+		return nil, nil
+	}
+	cursors := make([]Cursor, 0, len(m.orders))
+	for _, o := range m.orders {
+		cursors = append(cursors, *CursorOf(o))
+	}
+	slices.SortFunc(cursors, func(a, b Cursor) int {
+		switch {
+		case b.less(a):
+			return -1
+		case a.less(b):
+			return 1
+		}
+		return 0
+	})
+	return &cursors[n-1], nil
+}
+
+func (m *Memory) FindOrderUIDs(ctx context.Context, key LookupKey, value string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if key != ByTrackNumber {
+		value = canonicalUID(value)
+	}
+	var found []model.Order
+	for _, o := range m.orders {
+		if key.has(o, value) {
+			found = append(found, o)
+		}
+	}
+	slices.SortFunc(found, func(a, b model.Order) int {
+		return b.DateCreated.Compare(a.DateCreated)
+	})
+
+	uids := make([]string, 0, len(found))
+	for _, o := range found[:min(len(found), MaxListLimit)] {
+		uids = append(uids, o.OrderUID)
+	}
+	return uids, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, orderUID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	orderUID = canonicalUID(orderUID)
+	if _, ok := m.orders[orderUID]; !ok {
+		return ErrNotFound
+	}
+	delete(m.orders, orderUID)
+	return nil
+}
+
+func (m *Memory) Stream(ctx context.Context, fn func(model.Order) error) error {
+	q := ListQuery{Limit: DefaultListLimit}
+	for {
+		page, err := m.List(ctx, q)
+		if err != nil || len(page) == 0 {
+			return err
+		}
+		for _, o := range page {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(o); err != nil {
+				if errors.Is(err, StopStream) {
+					return nil
+				}
+				return err
+			}
+		}
+		q.After = CursorOf(page[len(page)-1])
+	}
+}
+
+// clone копирует слайс позиций, чтобы вызывающий код не мог изменить хранимый заказ.
+func clone(o model.Order) model.Order {
+	o.Items = slices.Clone(o.Items)
+	return o
+}
@@ -0,0 +1,651 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/model"
+)
+
+// Postgres хранит заказы в таблицах orders, deliveries, payments и items.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// selectOrders выбирает заказ вместе с доставкой, оплатой и позициями:
+// по одной строке на позицию. Строки одного заказа идут подряд.
+const selectOrders = `
+SELECT
+    o.order_uid,
+    o.track_number,
+    o.entry,
+    COALESCE(o.locale, ''),
+    COALESCE(o.internal_signature, ''),
+    COALESCE(o.customer_id, ''),
+    COALESCE(o.delivery_service, ''),
+    COALESCE(o.shardkey, 0),
+    COALESCE(o.sm_id, 0),
+    o.date_created,
+    COALESCE(o.oof_shard, 0),
+    o.status,
+    o.version,
+    COALESCE(o.updated_at, o.date_created),
+
+    COALESCE(d.name, ''),
+    COALESCE(d.phone, ''),
+    COALESCE(d.zip, ''),
+    COALESCE(d.city, ''),
+    COALESCE(d.address, ''),
+    COALESCE(d.region, ''),
+    COALESCE(d.email, ''),
+
+    COALESCE(p.transaction::text, ''),
+    COALESCE(p.request_id::text, ''),
+    COALESCE(p.currency, ''),
+    COALESCE(p.provider, ''),
+    COALESCE(p.amount, 0),
+    COALESCE(p.payment_dt, 0),
+    COALESCE(p.bank, ''),
+    COALESCE(p.delivery_cost, 0),
+    COALESCE(p.goods_total, 0),
+    COALESCE(p.custom_fee, 0),
+
+    i.id IS NOT NULL,
+    COALESCE(i.chrt_id, 0),
+    COALESCE(i.track_number, ''),
+    COALESCE(i.price, 0),
+    COALESCE(i.rid::text, ''),
+    COALESCE(i.name, ''),
+    COALESCE(i.sale, 0),
+    COALESCE(i.size, ''),
+    COALESCE(i.total_price, 0),
+    COALESCE(i.nm_id, 0),
+    COALESCE(i.brand, ''),
+    COALESCE(i.status, 0)
+FROM orders o
+LEFT JOIN deliveries d ON d.order_uid = o.order_uid
+LEFT JOIN payments p   ON p.order_uid = o.order_uid
+LEFT JOIN items i      ON i.order_uid = o.order_uid
+`
+
+const orderItemsOrder = `ORDER BY o.order_uid, i.chrt_id, i.rid`
+
+func (p *Postgres) Get(ctx context.Context, orderUID string) (model.Order, error) {
+	if _, err := uuid.Parse(orderUID); err != nil {
+		// order_uid - UUID, невалидная строка не может существовать
+		return model.Order{}, ErrNotFound
+	}
+
+	orders, err := p.query(ctx, selectOrders+`WHERE o.order_uid = $1 `+orderItemsOrder, orderUID)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if len(orders) == 0 {
+		return model.Order{}, ErrNotFound
+	}
+	return orders[0], nil
+}
+
+func (p *Postgres) List(ctx context.Context, q ListQuery) ([]model.Order, error) {
+	var (
+		where []string
+		args  []any
+	)
+	cond := func(format string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(format, len(args)))
+	}
+
+	if q.CustomerID != "" {
+		cond("o.customer_id = $%d", q.CustomerID)
+	}
+	if q.TrackNumber != "" {
+		cond("o.track_number = $%d", q.TrackNumber)
+	}
+	if q.DeliveryService != "" {
+		cond("o.delivery_service = $%d", q.DeliveryService)
+	}
+	if q.Entry != "" {
+		cond("o.entry = $%d", q.Entry)
+	}
+	if q.PaymentProvider != "" {
+		cond("EXISTS (SELECT 1 FROM payments fp WHERE fp.order_uid = o.order_uid AND fp.provider = $%d)", q.PaymentProvider)
+	}
+	if q.Brand != "" {
+		cond("EXISTS (SELECT 1 FROM items fi WHERE fi.order_uid = o.order_uid AND fi.brand = $%d)", q.Brand)
+	}
+	if q.Status != "" {
+		cond("o.status = $%d", q.Status)
+	}
+	if !q.CreatedFrom.IsZero() {
+		cond("o.date_created >= $%d", q.CreatedFrom)
+	}
+	if !q.CreatedTo.IsZero() {
+		cond("o.date_created < $%d", q.CreatedTo)
+	}
+
+	dir, cmp := "ASC", ">"
+	if q.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if q.After != nil {
+		if _, err := uuid.Parse(q.After.OrderUID); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		args = append(args, q.After.DateCreated, q.After.OrderUID)
+		where = append(where, fmt.Sprintf("(o.date_created, o.order_uid) %s ($%d, $%d)", cmp, len(args)-1, len(args)))
+	}
+
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, q.limit())
+	order := fmt.Sprintf("ORDER BY o.date_created %[1]s, o.order_uid %[1]s", dir)
+
+	return p.query(ctx, selectOrders+fmt.Sprintf(`
+WHERE o.order_uid IN (
+    SELECT o.order_uid FROM orders o %s %s LIMIT $%d
+) %s, i.chrt_id, i.rid`, filter, order, len(args), order), args...)
+}
+
+func (p *Postgres) NthNewest(ctx context.Context, n int) (*Cursor, error) {
+	if n < 1 {
+		return nil, nil
+	}
+	var c Cursor
+	err := p.db.QueryRowContext(ctx, `
+		SELECT date_created, order_uid FROM orders
+		ORDER BY date_created DESC, order_uid DESC OFFSET $1 LIMIT 1`, n-1).Scan(&c.DateCreated, &c.OrderUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("nth newest order query failed: %w", err)
+	}
+	return &c, nil
+}
+
+// lookupQueries выбирают order_uid по значению поля LookupKey; $2 - лимит.
+var lookupQueries = map[LookupKey]string{
+	ByTrackNumber: `SELECT order_uid FROM orders WHERE track_number = $1
+		ORDER BY date_created DESC LIMIT $2`,
+	ByTransaction: `SELECT o.order_uid FROM payments p JOIN orders o ON o.order_uid = p.order_uid
+		WHERE p.transaction = $1 ORDER BY o.date_created DESC LIMIT $2`,
+	ByItemRid: `SELECT o.order_uid FROM orders o
+		WHERE o.order_uid IN (SELECT order_uid FROM items WHERE rid = $1)
+		ORDER BY o.date_created DESC LIMIT $2`,
+}
+
+func (p *Postgres) FindOrderUIDs(ctx context.Context, key LookupKey, value string) ([]string, error) {
+	query, ok := lookupQueries[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown lookup key %q", key)
+	}
+	if key != ByTrackNumber {
+		// transaction и rid - UUID, остальные значения искать бессмысленно
+		if _, err := uuid.Parse(value); err != nil {
+			return nil, nil
+		}
+	}
+
+	rows, err := p.db.QueryContext(ctx, query, value, MaxListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("lookup by %s failed: %w", key, err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("lookup by %s failed: %w", key, err)
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("lookup by %s failed: %w", key, err)
+	}
+	return uids, nil
+}
+
+func (p *Postgres) Stream(ctx context.Context, fn func(model.Order) error) error {
+	q := ListQuery{Limit: DefaultListLimit}
+	for {
+		page, err := p.List(ctx, q)
+		if err != nil || len(page) == 0 {
+			return err
+		}
+		for _, o := range page {
+			if err := fn(o); err != nil {
+				if errors.Is(err, StopStream) {
+					return nil
+				}
+				return err
+			}
+		}
+		q.After = CursorOf(page[len(page)-1])
+	}
+}
+
+func (p *Postgres) Delete(ctx context.Context, orderUID string) error {
+	if _, err := uuid.Parse(orderUID); err != nil {
+		return ErrNotFound
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"items", "payments", "deliveries"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE order_uid=$1`, orderUID); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM orders WHERE order_uid=$1`, orderUID)
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// query выполняет selectOrders с условием и собирает строки в заказы.
+func (p *Postgres) query(ctx context.Context, query string, args ...any) ([]model.Order, error) {
+	return queryOrders(ctx, p.db, query, args...)
+}
+
+// queryer - *sql.DB или *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func queryOrders(ctx context.Context, db queryer, query string, args ...any) ([]model.Order, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("orders query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		var (
+			order   model.Order
+			itm     model.Item
+			hasItem bool
+		)
+		err := rows.Scan(
+			&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSig,
+			&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SmID,
+			&order.DateCreated, &order.OofShard, &order.Status, &order.Version, &order.UpdatedAt,
+
+			&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
+			&order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+
+			&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency,
+			&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
+			&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
+
+			&hasItem,
+			&itm.ChrtID, &itm.TrackNumber, &itm.Price, &itm.Rid,
+			&itm.Name, &itm.Sale, &itm.Size, &itm.TotalPrice,
+			&itm.NmID, &itm.Brand, &itm.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("orders scan failed: %w", err)
+		}
+
+		if n := len(orders); n == 0 || orders[n-1].OrderUID != order.OrderUID {
+			order.Items = []model.Item{}
+			orders = append(orders, order)
+		}
+		if hasItem {
+			last := &orders[len(orders)-1]
+			last.Items = append(last.Items, itm)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("orders query failed: %w", err)
+	}
+	return orders, nil
+}
+
+// Upsert записывает заказ со всеми связанными сущностями в одной транзакции.
+// Доставка, оплата и позиции заменяются целиком.
+func (p *Postgres) Upsert(ctx context.Context, order *model.Order, src *model.Source) error {
+	orderID, txUUID, rids, err := parseUUIDs(*order)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if order.UpdatedAt.IsZero() {
+		order.UpdatedAt = time.Now()
+	}
+
+	// статус при перезаписи не трогаем: он меняется только через UpdateStatus.
+	// Более старый снимок заказа не перезаписывает новый: строка не
+	// обновляется, RETURNING ничего не возвращает.
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO orders (
+			order_uid, track_number, entry, locale, internal_signature,
+			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		)
+		ON CONFLICT (order_uid) DO UPDATE
+		SET track_number = EXCLUDED.track_number,
+			entry = EXCLUDED.entry,
+			locale = EXCLUDED.locale,
+			internal_signature = EXCLUDED.internal_signature,
+			customer_id = EXCLUDED.customer_id,
+			delivery_service = EXCLUDED.delivery_service,
+			shardkey = EXCLUDED.shardkey,
+			sm_id = EXCLUDED.sm_id,
+			date_created = EXCLUDED.date_created,
+			oof_shard = EXCLUDED.oof_shard,
+			updated_at = EXCLUDED.updated_at
+		WHERE orders.updated_at IS NULL OR orders.updated_at <= EXCLUDED.updated_at
+		RETURNING status, version`,
+		sql.Named("order_uid", orderID),
+		sql.Named("track_number", order.TrackNumber),
+		sql.Named("entry", order.Entry),
+		sql.Named("locale", order.Locale),
+		sql.Named("internal_signature", order.InternalSig),
+		sql.Named("customer_id", order.CustomerID),
+		sql.Named("delivery_service", order.DeliveryService),
+		sql.Named("shardkey", order.ShardKey),
+		sql.Named("sm_id", order.SmID),
+		sql.Named("date_created", order.DateCreated),
+		sql.Named("oof_shard", order.OofShard),
+		sql.Named("updated_at", order.UpdatedAt),
+	).Scan(&order.Status, &order.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrStale
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM deliveries WHERE order_uid=$1`,
+		sql.Named("order_uid", orderID))
+	if err != nil {
+		return fmt.Errorf("failed to delete old delivery: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO deliveries (id, order_uid, name, phone, zip, city, address, region, email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		sql.Named("id", uuid.New()),
+		sql.Named("order_uid", orderID),
+		sql.Named("name", order.Delivery.Name),
+		sql.Named("phone", order.Delivery.Phone),
+		sql.Named("zip", order.Delivery.Zip),
+		sql.Named("city", order.Delivery.City),
+		sql.Named("address", order.Delivery.Address),
+		sql.Named("region", order.Delivery.Region),
+		sql.Named("email", order.Delivery.Email),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert delivery: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM payments WHERE order_uid=$1`,
+		sql.Named("order_uid", orderID))
+	if err != nil {
+		return fmt.Errorf("failed to delete old payment: %w", err)
+	}
+	reqID := sql.NullString{String: order.Payment.RequestID, Valid: order.Payment.RequestID != ""}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO payments (
+			id, order_uid, transaction, request_id, currency, provider,
+			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		)`,
+		sql.Named("id", uuid.New()),
+		sql.Named("order_uid", orderID),
+		sql.Named("transaction", txUUID),
+		sql.Named("request_id", reqID),
+		sql.Named("currency", order.Payment.Currency),
+		sql.Named("provider", order.Payment.Provider),
+		sql.Named("amount", order.Payment.Amount),
+		sql.Named("payment_dt", order.Payment.PaymentDT),
+		sql.Named("bank", order.Payment.Bank),
+		sql.Named("delivery_cost", order.Payment.DeliveryCost),
+		sql.Named("goods_total", order.Payment.GoodsTotal),
+		sql.Named("custom_fee", order.Payment.CustomFee),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert payment: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM items WHERE order_uid=$1`,
+		sql.Named("order_uid", orderID))
+	if err != nil {
+		return fmt.Errorf("failed to delete old items: %w", err)
+	}
+	for i, it := range order.Items {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO items (
+				id, order_uid, chrt_id, track_number, price, rid,
+				name, sale, size, total_price, nm_id, brand, status
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+			)`,
+			sql.Named("id", uuid.New()),
+			sql.Named("order_uid", orderID),
+			sql.Named("chrt_id", it.ChrtID),
+			sql.Named("track_number", it.TrackNumber),
+			sql.Named("price", it.Price),
+			sql.Named("rid", rids[i]),
+			sql.Named("name", it.Name),
+			sql.Named("sale", it.Sale),
+			sql.Named("size", it.Size),
+			sql.Named("total_price", it.TotalPrice),
+			sql.Named("nm_id", it.NmID),
+			sql.Named("brand", it.Brand),
+			sql.Named("status", it.Status),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert item: %w", err)
+		}
+	}
+
+	if err = recordVersion(ctx, tx, order, src); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) UpdateStatus(ctx context.Context, orderUID string, to model.Status, src *model.Source, ifVersion int) (model.Status, error) {
+	if _, err := uuid.Parse(orderUID); err != nil {
+		return "", ErrNotFound
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		from    model.Status
+		version int
+	)
+	err = tx.QueryRowContext(ctx, `SELECT status, version FROM orders WHERE order_uid = $1 FOR UPDATE`, orderUID).
+		Scan(&from, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read status: %w", err)
+	}
+	if ifVersion != AnyVersion && ifVersion != version {
+		return from, versionMismatch(ifVersion, version)
+	}
+	if err := transition(from, to); err != nil {
+		return from, err
+	}
+	if from == to {
+		return from, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE orders SET status = $2, status_updated_at = now() WHERE order_uid = $1`,
+		orderUID, to)
+	if err != nil {
+		return "", fmt.Errorf("failed to update status: %w", err)
+	}
+	orders, err := queryOrders(ctx, tx, selectOrders+`WHERE o.order_uid = $1 `+orderItemsOrder, orderUID)
+	if err != nil {
+		return "", err
+	}
+	if err = recordVersion(ctx, tx, &orders[0], src); err != nil {
+		return "", err
+	}
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return from, nil
+}
+
+// recordVersion сохраняет заказ как новую версию, если он отличается от
+// последней сохранённой, и проставляет её номер в order.Version и
+// orders.version. Вызывается в транзакции, которая уже держит блокировку
+// строки заказа, поэтому номера версий не конфликтуют.
+func recordVersion(ctx context.Context, tx queryer, order *model.Order, src *model.Source) error {
+	var (
+		last int
+		prev []byte
+	)
+	err := tx.QueryRowContext(ctx, `
+		SELECT version, data FROM order_versions
+		WHERE order_uid = $1 ORDER BY version DESC LIMIT 1`, order.OrderUID).Scan(&last, &prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read last version: %w", err)
+	}
+
+	order.Version = last + 1
+	data, changes, err := versionDiff(*order, prev)
+	if err != nil {
+		return err
+	}
+	if last > 0 && len(changes) == 0 {
+		// повторная доставка того же заказа
+		order.Version = last
+		return nil
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	var topic sql.NullString
+	var partition, offset sql.NullInt64
+	if src != nil {
+		topic = sql.NullString{String: src.Topic, Valid: true}
+		partition = sql.NullInt64{Int64: int64(src.Partition), Valid: true}
+		offset = sql.NullInt64{Int64: src.Offset, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO order_versions (
+			order_uid, version, source_topic, source_partition, source_offset, data, changes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		order.OrderUID, order.Version, topic, partition, offset, data, changesJSON)
+	if err != nil {
+		return fmt.Errorf("failed to insert version: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE orders SET version = $2 WHERE order_uid = $1`, order.OrderUID, order.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update version: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) History(ctx context.Context, orderUID string) ([]model.Version, error) {
+	if _, err := uuid.Parse(orderUID); err != nil {
+		return nil, nil
+	}
+	return p.versions(ctx, `
+		SELECT version, received_at, source_topic, source_partition, source_offset, changes, NULL
+		FROM order_versions WHERE order_uid = $1 ORDER BY version`, orderUID)
+}
+
+func (p *Postgres) Version(ctx context.Context, orderUID string, n int) (model.Version, error) {
+	if _, err := uuid.Parse(orderUID); err != nil {
+		return model.Version{}, ErrVersionNotFound
+	}
+	versions, err := p.versions(ctx, `
+		SELECT version, received_at, source_topic, source_partition, source_offset, changes, data
+		FROM order_versions WHERE order_uid = $1 AND version = $2`, orderUID, n)
+	if err != nil {
+		return model.Version{}, err
+	}
+	if len(versions) == 0 {
+		return model.Version{}, ErrVersionNotFound
+	}
+	return versions[0], nil
+}
+
+func (p *Postgres) versions(ctx context.Context, query string, args ...any) ([]model.Version, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("versions query failed: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []model.Version{}
+	for rows.Next() {
+		var (
+			v                 model.Version
+			topic             sql.NullString
+			partition, offset sql.NullInt64
+			changes, data     []byte
+		)
+		if err := rows.Scan(&v.Number, &v.ReceivedAt, &topic, &partition, &offset, &changes, &data); err != nil {
+			return nil, fmt.Errorf("versions scan failed: %w", err)
+		}
+		if topic.Valid {
+			v.Source = &model.Source{Topic: topic.String, Partition: int32(partition.Int64), Offset: offset.Int64}
+		}
+		if err := json.Unmarshal(changes, &v.Changes); err != nil {
+			return nil, fmt.Errorf("version %d: %w", v.Number, err)
+		}
+		if data != nil {
+			v.Order = data
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("versions query failed: %w", err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,261 @@
+// Package repository отвечает за хранение заказов.
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/apperr"
+	"order-service/internal/model"
+)
+
+// ErrNotFound возвращается, если заказа с таким order_uid нет.
+var ErrNotFound = apperr.New(apperr.NotFound, "order not found")
+
+// ErrStale возвращает Upsert, если в хранилище уже лежит более свежий
+// снимок заказа (по UpdatedAt).
+var ErrStale = apperr.New(apperr.Conflict, "a newer version of the order is already stored")
+
+// AnyVersion в UpdateStatus означает изменение без проверки версии.
+// Отдельное значение нужно потому, что у заказов, записанных до появления
+// истории версий, версия 0.
+const AnyVersion = -1
+
+// ErrVersionNotFound возвращается, если у заказа нет версии с таким номером.
+var ErrVersionNotFound = apperr.New(apperr.NotFound, "order version not found")
+
+// StopStream можно вернуть из колбэка Stream, чтобы остановить обход без ошибки.
+var StopStream = errors.New("stop stream")
+
+// ErrInvalidCursor возвращается, если курсор страницы не удалось разобрать.
+var ErrInvalidCursor = apperr.New(apperr.Invalid, "invalid cursor")
+
+const (
+	// DefaultListLimit используется, если ListQuery.Limit не задан.
+	DefaultListLimit = 100
+	// MaxListLimit - максимальный размер страницы.
+	MaxListLimit = 500
+)
+
+// ListQuery задаёт фильтры и страницу для List. Заказы упорядочены по
+// (date_created, order_uid), по возрастанию или по убыванию.
+// Пустые поля фильтра не применяются.
+type ListQuery struct {
+	Limit int
+	// After - позиция последнего заказа предыдущей страницы.
+	After *Cursor
+	Desc  bool
+
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Entry           string
+	PaymentProvider string
+	Brand           string
+	Status          model.Status
+	// CreatedFrom включительно, CreatedTo - не включительно.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// LookupKey - поле, по которому можно найти заказы через FindOrderUIDs.
+type LookupKey string
+
+const (
+	ByTrackNumber LookupKey = "track_number"
+	ByTransaction LookupKey = "transaction"
+	ByItemRid     LookupKey = "item_rid"
+)
+
+// Cursor - позиция заказа в выдаче List.
+type Cursor struct {
+	DateCreated time.Time
+	OrderUID    string
+}
+
+// CursorOf возвращает позицию заказа для продолжения выдачи после него.
+func CursorOf(o model.Order) *Cursor {
+	return &Cursor{DateCreated: o.DateCreated, OrderUID: o.OrderUID}
+}
+
+// Encode сериализует курсор в непрозрачную строку для API.
+func (c Cursor) Encode() string {
+	raw := c.DateCreated.UTC().Format(time.RFC3339Nano) + "|" + c.OrderUID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor разбирает строку, полученную из Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	ts, uid, ok := strings.Cut(string(raw), "|")
+	if !ok || uid == "" {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return &Cursor{DateCreated: t, OrderUID: uid}, nil
+}
+
+// OrderRepository - хранилище заказов.
+type OrderRepository interface {
+	// Get возвращает заказ или ErrNotFound.
+	Get(ctx context.Context, orderUID string) (model.Order, error)
+	// Upsert создаёт заказ или полностью заменяет существующий и сохраняет
+	// его как новую версию, если он изменился. src - откуда пришёл заказ,
+	// nil для изменений через API. Заказ с UpdatedAt старше сохранённого не
+	// записывается, возвращается ErrStale; пустой UpdatedAt - текущее время.
+	// После записи order.Status и order.Version - как в хранилище.
+	Upsert(ctx context.Context, order *model.Order, src *model.Source) error
+	// List возвращает одну страницу заказов, подходящих под фильтры.
+	List(ctx context.Context, q ListQuery) ([]model.Order, error)
+	// NthNewest возвращает позицию n-го по date_created заказа, считая от
+	// самого нового с единицы, или nil, если заказов меньше n.
+	NthNewest(ctx context.Context, n int) (*Cursor, error)
+	// FindOrderUIDs возвращает order_uid заказов (не больше MaxListLimit),
+	// у которых поле key равно value, от новых к старым.
+	FindOrderUIDs(ctx context.Context, key LookupKey, value string) ([]string, error)
+	// UpdateStatus переводит заказ в статус to и возвращает прежний статус.
+	// Недопустимый переход - ошибка вида apperr.Conflict, повтор текущего
+	// статуса ничего не меняет. Если ifVersion не AnyVersion и не совпадает с текущей
+	// версией заказа, возвращается ошибка вида apperr.FailedPrecondition.
+	UpdateStatus(ctx context.Context, orderUID string, to model.Status, src *model.Source, ifVersion int) (model.Status, error)
+	// History возвращает все версии заказа без данных заказа, от первой
+	// к последней. Заказ без сохранённых версий даёт пустой список.
+	History(ctx context.Context, orderUID string) ([]model.Version, error)
+	// Version возвращает версию n вместе с заказом или ErrVersionNotFound.
+	Version(ctx context.Context, orderUID string, n int) (model.Version, error)
+	// Delete удаляет заказ или возвращает ErrNotFound.
+	Delete(ctx context.Context, orderUID string) error
+	// Stream обходит все заказы, пока fn не вернёт ошибку.
+	// Если fn вернула StopStream, Stream возвращает nil.
+	Stream(ctx context.Context, fn func(model.Order) error) error
+}
+
+func (q ListQuery) limit() int {
+	if q.Limit <= 0 {
+		return DefaultListLimit
+	}
+	return min(q.Limit, MaxListLimit)
+}
+
+// less сообщает, идёт ли заказ a раньше позиции b при сортировке по возрастанию.
+func (c Cursor) less(b Cursor) bool {
+	if !c.DateCreated.Equal(b.DateCreated) {
+		return c.DateCreated.Before(b.DateCreated)
+	}
+	return c.OrderUID < b.OrderUID
+}
+
+// has сообщает, совпадает ли поле key заказа со значением value.
+func (key LookupKey) has(o model.Order, value string) bool {
+	switch key {
+	case ByTrackNumber:
+		return o.TrackNumber == value
+	case ByTransaction:
+		return o.Payment.Transaction == value
+	case ByItemRid:
+		for _, it := range o.Items {
+			if it.Rid == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseUUIDs разбирает идентификаторы заказа, которые хранятся в колонках uuid.
+func parseUUIDs(o model.Order) (orderID, txID uuid.UUID, rids []uuid.UUID, err error) {
+	if orderID, err = uuid.Parse(o.OrderUID); err != nil {
+		return orderID, txID, nil, fmt.Errorf("invalid order_uid: %w", err)
+	}
+	if txID, err = uuid.Parse(o.Payment.Transaction); err != nil {
+		return orderID, txID, nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+	rids = make([]uuid.UUID, len(o.Items))
+	for i, it := range o.Items {
+		if rids[i], err = uuid.Parse(it.Rid); err != nil {
+			return orderID, txID, nil, fmt.Errorf("invalid item.rid: %w", err)
+		}
+	}
+	return orderID, txID, rids, nil
+}
+
+// canonicalUID приводит UUID к виду, в котором его возвращает Postgres;
+// остальные строки возвращает как есть.
+func canonicalUID(s string) string {
+	if id, err := uuid.Parse(s); err == nil {
+		return id.String()
+	}
+	return s
+}
+
+// transition проверяет переход статуса и переводит запрет в доменную ошибку.
+func transition(from, to model.Status) error {
+	err := model.Transition(from, to)
+	if errors.Is(err, model.ErrIllegalTransition) {
+		return apperr.Wrap(apperr.Conflict, err, fmt.Sprintf("order status cannot change from %s to %s", from, to))
+	}
+	if err != nil {
+		return apperr.Wrap(apperr.Invalid, err, err.Error())
+	}
+	return nil
+}
+
+// versionDiff сериализует заказ и сравнивает его с предыдущей версией prev.
+// Смена самого номера версии изменением не считается.
+func versionDiff(order model.Order, prev []byte) ([]byte, []model.Change, error) {
+	data, err := model.Encode(order)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := model.Diff(prev, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes = slices.DeleteFunc(changes, func(c model.Change) bool { return c.Path == "version" })
+	if changes == nil {
+		changes = []model.Change{}
+	}
+	return data, changes, nil
+}
+
+// versionMismatch - ошибка для условного изменения по устаревшей версии.
+func versionMismatch(want, have int) error {
+	return apperr.New(apperr.FailedPrecondition, "order version is %d, not %d", have, want)
+}
+
+// match проверяет заказ на соответствие фильтрам (без учёта курсора).
+func (q ListQuery) match(o model.Order) bool {
+	switch {
+	case q.CustomerID != "" && o.CustomerID != q.CustomerID,
+		q.TrackNumber != "" && o.TrackNumber != q.TrackNumber,
+		q.DeliveryService != "" && o.DeliveryService != q.DeliveryService,
+		q.Entry != "" && o.Entry != q.Entry,
+		q.PaymentProvider != "" && o.Payment.Provider != q.PaymentProvider,
+		q.Status != "" && o.Status != q.Status,
+		!q.CreatedFrom.IsZero() && o.DateCreated.Before(q.CreatedFrom),
+		!q.CreatedTo.IsZero() && !o.DateCreated.Before(q.CreatedTo):
+		return false
+	}
+	if q.Brand != "" {
+		for _, it := range o.Items {
+			if it.Brand == q.Brand {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+// Package validation содержит структурированные ошибки валидации и
+// проверки отдельных полей, общие для всех моделей сервиса.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Коды ошибок полей.
+const (
+	CodeRequired = "required"
+	CodeFormat   = "invalid_format"
+	CodeRange    = "out_of_range"
+	CodeMismatch = "mismatch"
+)
+
+// FieldError описывает нарушение правила для одного поля.
+// Field - путь к полю в JSON, например "items[1].track_number".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors - список ошибок полей. Пустой список означает, что объект валиден.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add добавляет ошибку для поля field.
+func (e *Errors) Add(field, code, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Required добавляет ошибку, если значение пустое.
+func (e *Errors) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		e.Add(field, CodeRequired, "must not be empty")
+		return false
+	}
+	return true
+}
+
+// UUID добавляет ошибку, если значение не является UUID.
+func (e *Errors) UUID(field, value string) bool {
+	if !e.Required(field, value) {
+		return false
+	}
+	if _, err := uuid.Parse(value); err != nil {
+		e.Add(field, CodeFormat, "must be a UUID")
+		return false
+	}
+	return true
+}
+
+// NonNegative добавляет ошибку, если значение меньше нуля.
+func (e *Errors) NonNegative(field string, value int64) bool {
+	if value < 0 {
+		e.Add(field, CodeRange, "must not be negative, got %d", value)
+		return false
+	}
+	return true
+}
+
+// Err возвращает nil для пустого списка, чтобы результат можно было
+// использовать как обычную ошибку.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// IsEmail проверяет, что строка похожа на одиночный e-mail адрес.
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	at := strings.LastIndexByte(s, '@')
+	return at > 0 && strings.Contains(s[at+1:], ".")
+}
+
+// IsPhone проверяет, что строка похожа на телефонный номер: от 7 до 15 цифр
+// (E.164) с допустимыми разделителями и необязательным добавочным номером.
+func IsPhone(s string) bool {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, "xX"); i > 0 {
+		if !allDigits(strings.TrimSpace(s[i+1:])) {
+			return false
+		}
+		s = strings.TrimSpace(s[:i])
+	}
+	s = strings.TrimPrefix(s, "+")
+	digits := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune(" -().", r):
+		default:
+			return false
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// IsCurrency проверяет, что строка является действующим кодом валюты ISO 4217.
+func IsCurrency(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	_, ok := currencies[s]
+	return ok
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}